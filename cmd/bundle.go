@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/bundle"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd represents the export command
+var ExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Export the storages, metas, users, settings, sharings and ssh keys to a bundle",
+	Long: `Export the storages, metas, users, settings, sharings and ssh keys to a json or yaml bundle,
+the format is chosen by the extension of the file. The bundle is written to stdout if the file is omitted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		redact, _ := cmd.Flags().GetBool("redact")
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" && len(args) > 0 {
+			format = bundle.FormatOf(args[0])
+		}
+		bootstrap.Init()
+		defer bootstrap.Release()
+		b, err := bundle.Export(redact)
+		if err != nil {
+			return fmt.Errorf("failed to export: %+v", err)
+		}
+		data, err := bundle.Marshal(b, format)
+		if err != nil {
+			return fmt.Errorf("failed to encode bundle: %+v", err)
+		}
+		if len(args) == 0 {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err = os.WriteFile(args[0], data, 0o600); err != nil {
+			return fmt.Errorf("failed to write bundle: %+v", err)
+		}
+		utils.Log.Infof("exported the configuration to [%s] from CLI", args[0])
+		fmt.Printf("Exported %d storages, %d metas, %d users, %d settings, %d sharings and %d ssh keys to [%s]\n",
+			len(b.Storages), len(b.Metas), len(b.Users), len(b.Settings), len(b.Sharings), len(b.SSHKeys), args[0])
+		return nil
+	},
+}
+
+// ImportCmd represents the import command
+var ImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import a bundle exported by the export command",
+	Long: `Import a json or yaml bundle exported by the export command, the bundle is read from stdin if the file is "-".
+By default the bundle is merged into the configuration, storages, metas, users and sharings are matched by
+the mount path, path, username and id. With --replace they are all deleted before the import.
+Restart the server if it is running to load the imported configuration.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("file is required")
+		}
+		replace, _ := cmd.Flags().GetBool("replace")
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" {
+			format = bundle.FormatOf(args[0])
+		}
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %+v", err)
+		}
+		b, err := bundle.Unmarshal(data, format)
+		if err != nil {
+			return fmt.Errorf("failed to decode bundle: %+v", err)
+		}
+		bootstrap.Init()
+		defer bootstrap.Release()
+		res, err := bundle.Import(b, replace)
+		if err != nil {
+			return fmt.Errorf("failed to import: %+v", err)
+		}
+		utils.Log.Infof("imported the configuration from [%s] from CLI, replace: %t", args[0], replace)
+		fmt.Printf("Imported %d storages, %d metas, %d users, %d settings, %d sharings and %d ssh keys\n",
+			res.Storages, res.Metas, res.Users, res.Settings, res.Sharings, res.SSHKeys)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(ExportCmd)
+	RootCmd.AddCommand(ImportCmd)
+	ExportCmd.Flags().Bool("redact", false, "replace the confidential driver fields, e.g. passwords and tokens, with "+bundle.Redacted)
+	ExportCmd.Flags().String("format", "", "json or yaml, by the extension of the file by default")
+	ImportCmd.Flags().Bool("replace", false, "delete the storages, metas, users, sharings and ssh keys before the import")
+	ImportCmd.Flags().String("format", "", "json or yaml, by the extension of the file by default")
+}
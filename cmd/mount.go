@@ -0,0 +1,66 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command, it is only available in builds with the fuse tag
+var MountCmd = &cobra.Command{
+	Use:   "mount [path] [mountpoint]",
+	Short: "Mount a path of OpenList to a local directory with FUSE",
+	Long: `Mount a path of OpenList to a local directory with FUSE,
+the path is a path of the merged storage tree, e.g. / or /local.
+Operations are performed as the admin user.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("path and mountpoint are required")
+		}
+		opts, _ := cmd.Flags().GetStringArray("option")
+		bootstrap.Init()
+		defer bootstrap.Release()
+		admin, err := op.GetAdmin()
+		if err != nil {
+			return fmt.Errorf("failed get admin user: %+v", err)
+		}
+		bootstrap.LoadStorages()
+		<-conf.StoragesLoadSignal()
+
+		ctx := context.WithValue(context.Background(), conf.UserKey, admin)
+		ctx = context.WithValue(ctx, conf.ProtocolKey, audit.ProtocolFUSE)
+		host := fuse.NewHost(ctx, args[0])
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-quit
+			host.Unmount()
+		}()
+		mountOpts := make([]string, 0, len(opts)*2)
+		for _, o := range opts {
+			mountOpts = append(mountOpts, "-o", o)
+		}
+		utils.Log.Infof("mount [%s] to [%s]", args[0], args[1])
+		if !host.Mount(args[1], mountOpts) {
+			return fmt.Errorf("failed to mount [%s] to [%s]", args[0], args[1])
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().StringArrayP("option", "o", nil, "extra FUSE mount options, e.g. -o allow_other")
+}
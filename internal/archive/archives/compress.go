@@ -0,0 +1,63 @@
+package archives
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func (Archives) CompressFormats() []string {
+	return []string{"tar.gz"}
+}
+
+func (Archives) CanEncrypt(string) bool {
+	return false
+}
+
+func (Archives) NewWriter(w io.WriteSeeker, _, _ string) (tool.ArchiveWriter, error) {
+	gw := gzip.NewWriter(w)
+	return &TarGzWriter{gw: gw, tw: tar.NewWriter(gw)}, nil
+}
+
+type TarGzWriter struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func (w *TarGzWriter) WriteDir(name string, modTime time.Time) error {
+	return w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     strings.TrimSuffix(name, "/") + "/",
+		Mode:     0o755,
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	})
+}
+
+func (w *TarGzWriter) WriteFile(name string, size int64, modTime time.Time, r io.Reader) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0o644,
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = utils.CopyWithBuffer(w.tw, io.LimitReader(r, size))
+	return err
+}
+
+func (w *TarGzWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gw.Close()
+}
@@ -0,0 +1,265 @@
+package sevenzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// property ids of the 7z header, see 7zFormat.txt in the 7-Zip sources
+const (
+	idEnd              = 0x00
+	idHeader           = 0x01
+	idMainStreamsInfo  = 0x04
+	idFilesInfo        = 0x05
+	idPackInfo         = 0x06
+	idUnpackInfo       = 0x07
+	idSubStreamsInfo   = 0x08
+	idSize             = 0x09
+	idCRC              = 0x0a
+	idFolder           = 0x0b
+	idCodersUnpackSize = 0x0c
+	idEmptyStream      = 0x0e
+	idEmptyFile        = 0x0f
+	idName             = 0x11
+	idMTime            = 0x14
+	idWinAttributes    = 0x15
+)
+
+const (
+	signatureHeaderSize = 32
+	// attributes with the unix extension, the unix mode is stored in the high 16 bits
+	dirAttributes  = 0x10 | 0x8000 | (0o040755 << 16)
+	fileAttributes = 0x20 | 0x8000 | (0o100644 << 16)
+)
+
+var signature = []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0, 4}
+
+func (SevenZip) CompressFormats() []string {
+	return []string{"7z"}
+}
+
+func (SevenZip) CanEncrypt(string) bool {
+	return false
+}
+
+// NewWriter creates a 7z archive whose files are stored without compression,
+// so no codec other than Copy is required for writing
+func (SevenZip) NewWriter(w io.WriteSeeker, _, _ string) (tool.ArchiveWriter, error) {
+	start, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(make([]byte, signatureHeaderSize)); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start}, nil
+}
+
+type writerEntry struct {
+	name    string
+	modTime time.Time
+	dir     bool
+	size    uint64
+	crc     uint32
+}
+
+type Writer struct {
+	w       io.WriteSeeker
+	start   int64
+	packed  uint64
+	entries []writerEntry
+}
+
+func (w *Writer) WriteDir(name string, modTime time.Time) error {
+	w.entries = append(w.entries, writerEntry{
+		name:    strings.TrimSuffix(name, "/"),
+		modTime: modTime,
+		dir:     true,
+	})
+	return nil
+}
+
+func (w *Writer) WriteFile(name string, _ int64, modTime time.Time, r io.Reader) error {
+	h := crc32.NewIEEE()
+	n, err := utils.CopyWithBuffer(io.MultiWriter(w.w, h), r)
+	if err != nil {
+		return err
+	}
+	w.packed += uint64(n)
+	w.entries = append(w.entries, writerEntry{
+		name:    name,
+		modTime: modTime,
+		size:    uint64(n),
+		crc:     h.Sum32(),
+	})
+	return nil
+}
+
+func (w *Writer) Close() error {
+	header := w.header()
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	end, err := w.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	startHeader := make([]byte, 20)
+	binary.LittleEndian.PutUint64(startHeader[0:], w.packed)
+	binary.LittleEndian.PutUint64(startHeader[8:], uint64(len(header)))
+	binary.LittleEndian.PutUint32(startHeader[16:], crc32.ChecksumIEEE(header))
+	sh := make([]byte, 0, signatureHeaderSize)
+	sh = append(sh, signature...)
+	sh = binary.LittleEndian.AppendUint32(sh, crc32.ChecksumIEEE(startHeader))
+	sh = append(sh, startHeader...)
+	if _, err = w.w.Seek(w.start, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = w.w.Write(sh); err != nil {
+		return errors.WithMessage(err, "failed write signature header")
+	}
+	_, err = w.w.Seek(end, io.SeekStart)
+	return err
+}
+
+func (w *Writer) header() []byte {
+	var streams []writerEntry
+	emptyStream := make([]bool, len(w.entries))
+	var emptyFile []bool
+	for i, e := range w.entries {
+		if e.dir || e.size == 0 {
+			emptyStream[i] = true
+			emptyFile = append(emptyFile, !e.dir)
+		} else {
+			streams = append(streams, e)
+		}
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteByte(idHeader)
+	if len(streams) > 0 {
+		// every file is a folder with a single Copy coder
+		b.WriteByte(idMainStreamsInfo)
+		b.WriteByte(idPackInfo)
+		writeNumber(b, 0)
+		writeNumber(b, uint64(len(streams)))
+		b.WriteByte(idSize)
+		for _, s := range streams {
+			writeNumber(b, s.size)
+		}
+		b.WriteByte(idEnd)
+		b.WriteByte(idUnpackInfo)
+		b.WriteByte(idFolder)
+		writeNumber(b, uint64(len(streams)))
+		b.WriteByte(0) // not external
+		for range streams {
+			writeNumber(b, 1) // number of coders
+			b.WriteByte(0x01) // 1-byte codec id, simple coder without properties
+			b.WriteByte(0x00) // Copy
+		}
+		b.WriteByte(idCodersUnpackSize)
+		for _, s := range streams {
+			writeNumber(b, s.size)
+		}
+		b.WriteByte(idEnd)
+		b.WriteByte(idSubStreamsInfo)
+		b.WriteByte(idCRC)
+		b.WriteByte(1) // all defined
+		for _, s := range streams {
+			_ = binary.Write(b, binary.LittleEndian, s.crc)
+		}
+		b.WriteByte(idEnd)
+		b.WriteByte(idEnd)
+	}
+
+	b.WriteByte(idFilesInfo)
+	writeNumber(b, uint64(len(w.entries)))
+	if len(emptyFile) > 0 {
+		b.WriteByte(idEmptyStream)
+		bits := bitVector(emptyStream)
+		writeNumber(b, uint64(len(bits)))
+		b.Write(bits)
+		b.WriteByte(idEmptyFile)
+		bits = bitVector(emptyFile)
+		writeNumber(b, uint64(len(bits)))
+		b.Write(bits)
+	}
+	names := &bytes.Buffer{}
+	names.WriteByte(0) // not external
+	for _, e := range w.entries {
+		for _, c := range utf16.Encode([]rune(e.name)) {
+			_ = binary.Write(names, binary.LittleEndian, c)
+		}
+		names.Write([]byte{0, 0})
+	}
+	b.WriteByte(idName)
+	writeNumber(b, uint64(names.Len()))
+	b.Write(names.Bytes())
+	b.WriteByte(idMTime)
+	writeNumber(b, uint64(2+8*len(w.entries)))
+	b.Write([]byte{1, 0}) // all defined, not external
+	for _, e := range w.entries {
+		_ = binary.Write(b, binary.LittleEndian, toFileTime(e.modTime))
+	}
+	b.WriteByte(idWinAttributes)
+	writeNumber(b, uint64(2+4*len(w.entries)))
+	b.Write([]byte{1, 0}) // all defined, not external
+	for _, e := range w.entries {
+		attr := uint32(fileAttributes)
+		if e.dir {
+			attr = dirAttributes
+		}
+		_ = binary.Write(b, binary.LittleEndian, attr)
+	}
+	b.WriteByte(idEnd)
+	b.WriteByte(idEnd)
+	return b.Bytes()
+}
+
+// writeNumber writes v in the variable length encoding of 7z,
+// the count of leading one bits in the first byte is the count of the following bytes
+func writeNumber(b *bytes.Buffer, v uint64) {
+	var first byte
+	mask := byte(0x80)
+	i := 0
+	for ; i < 8; i++ {
+		if v < uint64(1)<<(7*(i+1)) {
+			first |= byte(v >> (8 * i))
+			break
+		}
+		first |= mask
+		mask >>= 1
+	}
+	b.WriteByte(first)
+	for ; i > 0; i-- {
+		b.WriteByte(byte(v))
+		v >>= 8
+	}
+}
+
+func bitVector(bits []bool) []byte {
+	ret := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			ret[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return ret
+}
+
+// toFileTime converts t to the count of 100ns intervals since 1601-01-01
+func toFileTime(t time.Time) uint64 {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return uint64(t.UnixNano()/100 + 116444736000000000)
+}
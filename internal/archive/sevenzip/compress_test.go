@@ -0,0 +1,106 @@
+package sevenzip
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bodgit/sevenzip"
+)
+
+func TestWriter(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.7z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := SevenZip{}.NewWriter(f, "7z", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := map[string]string{
+		"dir/a.txt":     "hello",
+		"dir/空.txt":     "",
+		"dir/sub/b.txt": strings.Repeat("openlist", 1000),
+	}
+	if err = w.WriteDir("dir", modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WriteDir("dir/sub", modTime); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dir/a.txt", "dir/空.txt", "dir/sub/b.txt"} {
+		content := files[name]
+		if err = w.WriteFile(name, int64(len(content)), modTime, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := sevenzip.OpenReader(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.File) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(r.File))
+	}
+	for _, file := range r.File {
+		name := strings.TrimSuffix(file.Name, "/")
+		if !file.Modified.Equal(modTime) {
+			t.Errorf("%s: unexpected mod time %v", name, file.Modified)
+		}
+		content, ok := files[name]
+		if !ok {
+			if !file.FileInfo().IsDir() {
+				t.Errorf("%s: expected a dir", name)
+			}
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, []byte(content)) {
+			t.Errorf("%s: content mismatch", name)
+		}
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "empty.7z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := SevenZip{}.NewWriter(f, "7z", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WriteDir("dir", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := sevenzip.OpenReader(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.File) != 1 || !r.File[0].FileInfo().IsDir() {
+		t.Fatalf("expected a single dir")
+	}
+}
@@ -3,6 +3,7 @@ package tool
 import (
 	"io"
 	"regexp"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
@@ -21,3 +22,20 @@ type Tool interface {
 	Extract(ss []*stream.SeekableStream, args model.ArchiveInnerArgs) (io.ReadCloser, int64, error)
 	Decompress(ss []*stream.SeekableStream, outputPath string, args model.ArchiveInnerArgs, up model.UpdateProgress) error
 }
+
+// Compressor is implemented by the tools which are able to create archives
+type Compressor interface {
+	// CompressFormats returns the formats can be passed to NewWriter, e.g. "zip", "tar.gz"
+	CompressFormats() []string
+	// CanEncrypt reports whether the format supports password protection
+	CanEncrypt(format string) bool
+	// NewWriter creates an archive on w, the password is always empty if CanEncrypt returns false
+	NewWriter(w io.WriteSeeker, format, password string) (ArchiveWriter, error)
+}
+
+type ArchiveWriter interface {
+	WriteDir(name string, modTime time.Time) error
+	WriteFile(name string, size int64, modTime time.Time, r io.Reader) error
+	// Close finishes the archive, the underlying writer will not be closed
+	Close() error
+}
@@ -0,0 +1,69 @@
+package zip
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KirCute/zip"
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// utf8Flag marks the names are encoded in UTF-8, the writer does not set it by itself
+const utf8Flag = 0x800
+
+func (z *Zip) CompressFormats() []string {
+	return []string{"zip"}
+}
+
+func (z *Zip) CanEncrypt(string) bool {
+	return true
+}
+
+func (z *Zip) NewWriter(w io.WriteSeeker, _, password string) (tool.ArchiveWriter, error) {
+	return &Writer{w: zip.NewWriter(w), password: password}, nil
+}
+
+// Writer writes a zip archive, the entries are encrypted with AES-256 if the password is set
+type Writer struct {
+	w        *zip.Writer
+	password string
+}
+
+func (w *Writer) WriteDir(name string, modTime time.Time) error {
+	fh := &zip.FileHeader{
+		Name:   strings.TrimSuffix(name, "/") + "/",
+		Flags:  utf8Flag,
+		Method: zip.Store,
+	}
+	fh.SetModTime(modTime)
+	fh.SetMode(os.ModeDir | 0o755)
+	_, err := w.w.CreateHeader(fh)
+	return err
+}
+
+func (w *Writer) WriteFile(name string, _ int64, modTime time.Time, r io.Reader) error {
+	fh := &zip.FileHeader{
+		Name:   name,
+		Flags:  utf8Flag,
+		Method: zip.Deflate,
+	}
+	fh.SetModTime(modTime)
+	fh.SetMode(0o644)
+	if w.password != "" {
+		fh.SetPassword(w.password)
+		fh.SetEncryptionMethod(zip.AES256Encryption)
+	}
+	fw, err := w.w.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	_, err = utils.CopyWithBuffer(fw, r)
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.w.Close()
+}
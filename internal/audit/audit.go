@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+// the protocols stored in the context with conf.ProtocolKey
+const (
+	ProtocolWeb    = "web"
+	ProtocolWebDAV = "webdav"
+	ProtocolFTP    = "ftp"
+	ProtocolSFTP   = "sftp"
+	ProtocolS3     = "s3"
+	ProtocolFUSE   = "fuse"
+)
+
+const (
+	// file operations
+	OpMkdir       = "mkdir"
+	OpRename      = "rename"
+	OpMove        = "move"
+	OpCopy        = "copy"
+	OpMerge       = "merge"
+	OpRemove      = "remove"
+	OpPut         = "put"
+	OpShareCreate = "share_create"
+
+	// trash
+	OpTrashRestore = "trash_restore"
+	OpTrashPurge   = "trash_purge"
+
+	// admin actions
+	OpStorageCreate  = "storage_create"
+	OpStorageUpdate  = "storage_update"
+	OpStorageDelete  = "storage_delete"
+	OpStorageEnable  = "storage_enable"
+	OpStorageDisable = "storage_disable"
+	OpUserCreate     = "user_create"
+	OpUserUpdate     = "user_update"
+	OpUserDelete     = "user_delete"
+	OpMetaCreate     = "meta_create"
+	OpMetaUpdate     = "meta_update"
+	OpMetaDelete     = "meta_delete"
+	OpSettingSave    = "setting_save"
+	OpSettingDelete  = "setting_delete"
+	OpResetToken     = "reset_token"
+	OpBundleImport   = "bundle_import"
+)
+
+// Record saves an audit log, the user, client ip and protocol are taken from ctx.
+// For admin actions src is the name of the changed object, e.g. the mount path of a storage.
+func Record(ctx context.Context, operation, src, dst string, err error) {
+	if !setting.GetBool(conf.AuditLogEnabled) {
+		return
+	}
+	l := &model.AuditLog{
+		Operation: operation,
+		SrcPath:   src,
+		DstPath:   dst,
+		Success:   err == nil,
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		l.Username = user.Username
+	}
+	l.IP, _ = ctx.Value(conf.ClientIPKey).(string)
+	l.Protocol, _ = ctx.Value(conf.ProtocolKey).(string)
+	if err != nil {
+		l.Message = err.Error()
+	}
+	if e := db.CreateAuditLog(l); e != nil {
+		log.Errorf("failed save audit log: %+v", e)
+	}
+}
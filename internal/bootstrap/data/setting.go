@@ -180,6 +180,20 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.HandleHookAfterWriting, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.HandleHookRateLimit, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.IgnoreSystemFiles, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `When enabled, ignores common system files during upload (.DS_Store, desktop.ini, Thumbs.db, and files starting with ._)`},
+		{Key: conf.AuditLogEnabled, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `record file operations and admin actions, see /api/admin/audit/list`},
+		{Key: conf.DefaultUserQuota, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `bytes a general user may upload in total unless set on the user, 0 means unlimited`},
+		{Key: conf.DefaultUserMaxFileSize, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max bytes of a single file uploaded by a general user unless set on the user, 0 means unlimited`},
+		{Key: conf.DefaultGuestQuota, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `bytes the guest may upload in total unless set on the guest user, 0 means unlimited`},
+		{Key: conf.DefaultGuestMaxFileSize, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max bytes of a single file uploaded by the guest unless set on the guest user, 0 means unlimited`},
+		{Key: conf.TrashRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days to keep the removed objects in the trash of the storages with trash enabled, 0 means forever`},
+		{Key: conf.StorageHealthInterval, Value: "5", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `minutes between the health checks listing the root of each storage, 0 disables the checks`},
+		{Key: conf.StorageHealthThreshold, Value: "3", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `consecutive failed health checks before the storage is reloaded or marked degraded`},
+		{Key: conf.StorageHealthReload, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `initialize the storage again once it failed the health checks, e.g. to refresh an expired token`},
+		{Key: conf.ShareAccessLogEnabled, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `record the visits of the sharings for the share stats`},
+		{Key: conf.ShareAccessLogDays, Value: "90", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days to keep the visits of the sharings, 0 means forever`},
+		{Key: conf.ShareAccessLogMax, Value: "10000", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max visits kept for a sharing, the oldest are deleted first, 0 means unlimited`},
+		{Key: conf.WebhookDeliveryLogDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days to keep the deliveries of the webhooks, 0 means forever`},
+		{Key: conf.WebhookDeliveryLogMax, Value: "1000", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max deliveries kept for a webhook, the oldest are deleted first, 0 means unlimited`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -187,7 +201,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
 		{Key: conf.MaxIndexDepth, Value: "20", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max depth of index`},
+		{Key: conf.SearchContent, Value: "false", Type: conf.TypeBool, Group: model.INDEX, Flag: model.PRIVATE, Help: `index the text of text, pdf, docx and odt files, only for bleve and meilisearch`},
+		{Key: conf.SearchContentMaxSize, Value: "10", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max size in MB of the files to index the text`},
 		{Key: conf.IndexProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
+		{Key: conf.S3PermissionsGranted, Value: "false", Type: conf.TypeBool, Group: model.SINGLE, Flag: model.PRIVATE},
 
 		// SSO settings
 		{Key: conf.SSOLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.SSO, Flag: model.PUBLIC},
@@ -238,6 +255,7 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.TaskCopyThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Copy.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Decompress.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressUploadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.DecompressUpload.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskCompressThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Compress.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
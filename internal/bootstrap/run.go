@@ -15,6 +15,9 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/index_job"
+	"github.com/OpenListTeam/OpenList/v4/internal/storage_health"
+	"github.com/OpenListTeam/OpenList/v4/internal/sync_job"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server"
 	"github.com/OpenListTeam/OpenList/v4/server/middlewares"
@@ -37,6 +40,8 @@ func Init() {
 	InitStreamLimit()
 	InitIndex()
 	InitUpgradePatch()
+	InitS3Permissions()
+	InitSharePwdHash()
 }
 
 func Release() {
@@ -92,6 +97,12 @@ func Start() {
 	InitOfflineDownloadTools()
 	LoadStorages()
 	InitTaskManager()
+	InitTrashPurge()
+	InitShareAccessPurge()
+	InitWebhookDeliveryPurge()
+	sync_job.Init()
+	index_job.Init()
+	storage_health.Init()
 	if !flags.Debug && !flags.Dev {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -0,0 +1,42 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// s3Permissions are 15(s3 read) and 16(s3 write)
+const s3Permissions int32 = 0x18000
+
+// InitS3Permissions gives the existing admin the S3 permissions once, without them the S3 keys of the admin
+// are refused. The tokens created with the full permission of the admin get them as well.
+// It runs at startup rather than as an upgrade patch, so it does not depend on the recorded version,
+// and the flag keeps it from granting them again once the admin drops them.
+func InitS3Permissions() {
+	if setting.GetBool(conf.S3PermissionsGranted) {
+		return
+	}
+	admin, err := op.GetAdmin()
+	if err == nil && admin.Permission&s3Permissions != s3Permissions {
+		if err = db.GrantAPITokensPermission(admin.ID, admin.Permission, s3Permissions); err == nil {
+			admin.Permission |= s3Permissions
+			err = op.UpdateUser(admin)
+		}
+	}
+	if err == nil {
+		err = op.SaveSettingItem(&model.SettingItem{
+			Key:   conf.S3PermissionsGranted,
+			Value: "true",
+			Type:  conf.TypeBool,
+			Group: model.SINGLE,
+			Flag:  model.PRIVATE,
+		})
+	}
+	if err != nil {
+		utils.Log.Errorf("Cannot grant S3 permissions to admin: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitShareAccessPurge deletes the share accesses beyond the retention hourly
+func InitShareAccessPurge() {
+	cron.NewCron(time.Hour).Do(func() {
+		if days := setting.GetInt(conf.ShareAccessLogDays, 90); days > 0 {
+			purged, err := db.DeleteShareAccessesBefore(time.Now().AddDate(0, 0, -days))
+			if err != nil {
+				log.Errorf("failed purge expired share accesses: %+v", err)
+			} else if purged > 0 {
+				log.Infof("purged %d expired share accesses", purged)
+			}
+		}
+		if limit := setting.GetInt(conf.ShareAccessLogMax, 10000); limit > 0 {
+			trimmed, err := db.TrimShareAccesses(limit)
+			if err != nil {
+				log.Errorf("failed trim share accesses: %+v", err)
+			} else if trimmed > 0 {
+				log.Infof("trimmed %d share accesses over the limit", trimmed)
+			}
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// InitSharePwdHash hashes the plain share codes saved by the old versions. It runs at every startup
+// rather than as an upgrade patch, so it does not depend on the recorded version,
+// as the plain share codes are no longer accepted.
+func InitSharePwdHash() {
+	sharings, err := db.GetSharingsWithPlainPwd()
+	if err != nil {
+		utils.Log.Errorf("failed get sharings with plain share codes: %v", err)
+		return
+	}
+	for i := range sharings {
+		s := &sharings[i]
+		if err = db.UpdateSharing(s.SetPwd(s.Pwd)); err != nil {
+			utils.Log.Errorf("failed hash the share code of sharing [%s]: %v", s.ID, err)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package bootstrap
 
 import (
-	"context"
-
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -10,25 +8,6 @@ import (
 	"golang.org/x/time/rate"
 )
 
-type blockBurstLimiter struct {
-	*rate.Limiter
-}
-
-func (l blockBurstLimiter) WaitN(ctx context.Context, total int) error {
-	for total > 0 {
-		n := l.Burst()
-		if l.Limiter.Limit() == rate.Inf || n > total {
-			n = total
-		}
-		err := l.Limiter.WaitN(ctx, n)
-		if err != nil {
-			return err
-		}
-		total -= n
-	}
-	return nil
-}
-
 func streamFilterNegative(limit int) (rate.Limit, int) {
 	if limit < 0 {
 		return rate.Inf, 0
@@ -36,9 +15,9 @@ func streamFilterNegative(limit int) (rate.Limit, int) {
 	return rate.Limit(limit) * 1024.0, limit * 1024
 }
 
-func initLimiter(limiter *stream.Limiter, s string) {
+func initLimiter(limiter *stream.Limiter, s, name string) {
 	clientDownLimit, burst := streamFilterNegative(setting.GetInt(s, -1))
-	*limiter = blockBurstLimiter{Limiter: rate.NewLimiter(clientDownLimit, burst)}
+	*limiter = stream.NewLimiter(clientDownLimit, burst, name)
 	op.RegisterSettingChangingCallback(func() {
 		newLimit, newBurst := streamFilterNegative(setting.GetInt(s, -1))
 		(*limiter).SetLimit(newLimit)
@@ -47,8 +26,8 @@ func initLimiter(limiter *stream.Limiter, s string) {
 }
 
 func InitStreamLimit() {
-	initLimiter(&stream.ClientDownloadLimit, conf.StreamMaxClientDownloadSpeed)
-	initLimiter(&stream.ClientUploadLimit, conf.StreamMaxClientUploadSpeed)
-	initLimiter(&stream.ServerDownloadLimit, conf.StreamMaxServerDownloadSpeed)
-	initLimiter(&stream.ServerUploadLimit, conf.StreamMaxServerUploadSpeed)
+	initLimiter(&stream.ClientDownloadLimit, conf.StreamMaxClientDownloadSpeed, "client_download")
+	initLimiter(&stream.ClientUploadLimit, conf.StreamMaxClientUploadSpeed, "client_upload")
+	initLimiter(&stream.ServerDownloadLimit, conf.StreamMaxServerDownloadSpeed, "server_download")
+	initLimiter(&stream.ServerUploadLimit, conf.StreamMaxServerUploadSpeed, "server_upload")
 }
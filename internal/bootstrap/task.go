@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -49,4 +50,16 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	fs.ArchiveCompressTaskManager = tache.NewManager[*fs.ArchiveCompressTask](tache.WithWorks(setting.GetInt(conf.TaskCompressThreadsNum, conf.Conf.Tasks.Compress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("compress", conf.Conf.Tasks.Compress.TaskPersistant), db.UpdateTaskDataFunc("compress", conf.Conf.Tasks.Compress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Compress.MaxRetry))
+	op.RegisterSettingChangingCallback(func() {
+		fs.ArchiveCompressTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCompressThreadsNum, conf.Conf.Tasks.Compress.Workers)))
+	})
+	metrics.RegisterTaskManager("upload", fs.UploadTaskManager)
+	metrics.RegisterTaskManager("copy", fs.CopyTaskManager)
+	metrics.RegisterTaskManager("move", fs.MoveTaskManager)
+	metrics.RegisterTaskManager("offline_download", tool.DownloadTaskManager)
+	metrics.RegisterTaskManager("offline_download_transfer", tool.TransferTaskManager)
+	metrics.RegisterTaskManager("decompress", fs.ArchiveDownloadTaskManager)
+	metrics.RegisterTaskManager("decompress_upload", fs.ArchiveContentUploadTaskManager)
+	metrics.RegisterTaskManager("compress", fs.ArchiveCompressTaskManager)
 }
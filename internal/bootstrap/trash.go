@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitTrashPurge purges the expired objects in the trash hourly
+func InitTrashPurge() {
+	cron.NewCron(time.Hour).Do(func() {
+		days := setting.GetInt(conf.TrashRetentionDays, 30)
+		if days <= 0 {
+			return
+		}
+		purged, err := fs.PurgeExpiredTrash(context.Background(), time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			log.Errorf("failed purge expired trash: %+v", err)
+		} else if purged > 0 {
+			log.Infof("purged %d expired objects in trash", purged)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitWebhookDeliveryPurge deletes the webhook deliveries beyond the retention hourly
+func InitWebhookDeliveryPurge() {
+	cron.NewCron(time.Hour).Do(func() {
+		if days := setting.GetInt(conf.WebhookDeliveryLogDays, 30); days > 0 {
+			purged, err := db.DeleteWebhookDeliveriesBefore(time.Now().AddDate(0, 0, -days))
+			if err != nil {
+				log.Errorf("failed purge expired webhook deliveries: %+v", err)
+			} else if purged > 0 {
+				log.Infof("purged %d expired webhook deliveries", purged)
+			}
+		}
+		if limit := setting.GetInt(conf.WebhookDeliveryLogMax, 1000); limit > 0 {
+			trimmed, err := db.TrimWebhookDeliveries(limit)
+			if err != nil {
+				log.Errorf("failed trim webhook deliveries: %+v", err)
+			} else if trimmed > 0 {
+				log.Infof("trimmed %d webhook deliveries over the limit", trimmed)
+			}
+		}
+	})
+}
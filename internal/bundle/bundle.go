@@ -0,0 +1,183 @@
+// Package bundle exports the configuration of the instance to a portable json or yaml bundle,
+// and imports a bundle by merging it into or replacing the configuration.
+package bundle
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the values of the confidential driver fields in a redacted bundle
+const Redacted = "<redacted>"
+
+// confidential matches the names of the driver fields holding credentials, e.g. refresh_token or client_secret
+var confidential = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|cookie|credential|private_key|access_key|api_key|authorization)`)
+
+// confidentialSettings are the settings holding credentials, they are redacted like the confidential driver fields
+var confidentialSettings = []string{conf.Token, conf.Aria2Secret, conf.QbittorrentUrl, conf.TransmissionUri,
+	conf.LdapManagerPassword, conf.SSOClientSecret, conf.S3AccessKeyId, conf.S3SecretAccessKey}
+
+// Export returns the configuration, the confidential driver fields and settings are replaced by Redacted
+// if redact is set. The otp secrets of the users are always redacted, only the password hashes are exported.
+func Export(redact bool) (*model.Bundle, error) {
+	b, err := db.LoadBundle()
+	if err != nil {
+		return nil, err
+	}
+	for i := range b.Users {
+		if b.Users[i].OtpSecret != "" {
+			b.Users[i].OtpSecret = Redacted
+		}
+	}
+	if redact {
+		for i := range b.Storages {
+			s := &b.Storages[i]
+			if s.Addition, err = redactAddition(s.Addition); err != nil {
+				return nil, errors.WithMessagef(err, "failed redact storage [%s]", s.MountPath)
+			}
+		}
+		redactSettings(b.Settings)
+	}
+	return b, nil
+}
+
+func redactSettings(settings map[string]string) {
+	for _, key := range confidentialSettings {
+		if settings[key] != "" {
+			settings[key] = Redacted
+		}
+	}
+}
+
+func redactAddition(addition string) (string, error) {
+	if addition == "" {
+		return addition, nil
+	}
+	var fields map[string]any
+	if err := utils.Json.UnmarshalFromString(addition, &fields); err != nil {
+		return "", errors.WithStack(err)
+	}
+	for k, v := range fields {
+		if s, ok := v.(string); ok && s != "" && confidential.MatchString(k) {
+			fields[k] = Redacted
+		}
+	}
+	return utils.Json.MarshalToString(fields)
+}
+
+// Import saves the bundle, see db.ImportBundle for the modes. The redacted driver fields are restored
+// from the storage of the same mount path and driver if there is one, otherwise they are left empty.
+// The redacted settings are kept, and the redacted otp secrets are restored from the user of the same
+// username, a user without one has to bind the otp again.
+func Import(b *model.Bundle, replace bool) (*model.BundleImportResult, error) {
+	if b.Version <= 0 || b.Version > model.BundleVersion {
+		return nil, errors.Errorf("unsupported bundle version: %d", b.Version)
+	}
+	if replace {
+		var admin, guest bool
+		for _, u := range b.Users {
+			admin = admin || u.Role == model.ADMIN
+			guest = guest || u.Role == model.GUEST
+		}
+		if !admin || !guest {
+			return nil, errors.New("the bundle must contain the admin and the guest to replace the users")
+		}
+	}
+	for i := range b.Storages {
+		s := &b.Storages[i]
+		s.MountPath = utils.FixAndCleanPath(s.MountPath)
+		if _, err := op.GetDriver(s.Driver); err != nil {
+			return nil, errors.WithMessagef(err, "invalid storage [%s]", s.MountPath)
+		}
+		if !strings.Contains(s.Addition, Redacted) {
+			continue
+		}
+		old, err := db.GetStorageByMountPath(s.MountPath)
+		if err != nil || old.Driver != s.Driver {
+			old = nil
+		}
+		if s.Addition, err = restoreAddition(s.Addition, old); err != nil {
+			return nil, errors.WithMessagef(err, "failed restore redacted fields of storage [%s]", s.MountPath)
+		}
+	}
+	for i := range b.Metas {
+		b.Metas[i].Path = utils.FixAndCleanPath(b.Metas[i].Path)
+	}
+	for i := range b.Users {
+		u := &b.Users[i]
+		if u.OtpSecret != Redacted {
+			continue
+		}
+		u.OtpSecret = ""
+		if old, err := db.GetUserByName(u.Username); err == nil {
+			u.OtpSecret = old.OtpSecret
+		}
+	}
+	for k, v := range b.Settings {
+		if v == Redacted {
+			delete(b.Settings, k)
+		}
+	}
+	return db.ImportBundle(b, replace)
+}
+
+func restoreAddition(addition string, old *model.Storage) (string, error) {
+	var fields, oldFields map[string]any
+	if err := utils.Json.UnmarshalFromString(addition, &fields); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if old != nil {
+		if err := utils.Json.UnmarshalFromString(old.Addition, &oldFields); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	for k, v := range fields {
+		if v != Redacted {
+			continue
+		}
+		if ov, ok := oldFields[k]; ok {
+			fields[k] = ov
+		} else {
+			fields[k] = ""
+		}
+	}
+	return utils.Json.MarshalToString(fields)
+}
+
+// Reload applies the imported configuration to the running instance, the caches are cleared
+// and the storages are loaded again in background
+func Reload() {
+	op.SettingCacheUpdate()
+	op.ClearUserCache()
+	op.ClearMetaCache()
+	op.ClearSharingCache()
+	conf.ResetStoragesLoadSignal()
+	go func() {
+		defer conf.SendStoragesLoadedSignal()
+		ctx := context.Background()
+		for _, storage := range op.GetAllStorages() {
+			if err := op.UnloadStorage(ctx, storage); err != nil {
+				log.Warnf("failed unload storage [%s]: %+v", storage.GetStorage().MountPath, err)
+			}
+		}
+		storages, err := db.GetEnabledStorages()
+		if err != nil {
+			log.Errorf("failed get enabled storages: %+v", err)
+			return
+		}
+		for _, storage := range storages {
+			if err := op.LoadStorage(ctx, storage); err != nil {
+				log.Errorf("failed load storage [%s]: %+v", storage.MountPath, err)
+			}
+		}
+	}()
+}
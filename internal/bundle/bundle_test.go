@@ -0,0 +1,138 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestRedactAndRestore(t *testing.T) {
+	redacted, err := redactAddition(`{"refresh_token":"rt","client_secret":"cs","root_folder_id":"0","password":""}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	_ = utils.Json.UnmarshalFromString(redacted, &fields)
+	if fields["refresh_token"] != Redacted || fields["client_secret"] != Redacted || fields["root_folder_id"] != "0" || fields["password"] != "" {
+		t.Fatalf("redacted = %s", redacted)
+	}
+	restored, err := restoreAddition(redacted, &model.Storage{Addition: `{"refresh_token":"new","root_folder_id":"1"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = utils.Json.UnmarshalFromString(restored, &fields)
+	if fields["refresh_token"] != "new" || fields["client_secret"] != "" || fields["root_folder_id"] != "0" {
+		t.Errorf("restored = %s", restored)
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	b := &model.Bundle{
+		Version:  model.BundleVersion,
+		Storages: []model.Storage{{ID: 3, MountPath: "/a", Driver: "Local", Addition: `{"root_folder_path":"/data"}`}},
+		Users: []model.BundleUser{{User: model.User{ID: 1, Username: "admin", Role: model.ADMIN, Quota: 1 << 40},
+			PwdHash: "hash", Salt: "salt", PwdTS: 1700000000}},
+		Settings: map[string]string{"site_title": "OpenList"},
+	}
+	data, err := Marshal(b, FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(data, FormatYAML)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	u := got.Users[0]
+	if u.Quota != 1<<40 || u.PwdHash != "hash" || u.Salt != "salt" || u.PwdTS != 1700000000 {
+		t.Errorf("user = %+v", u)
+	}
+	if s := got.Storages[0]; s.ID != 3 || s.MountPath != "/a" || s.Addition != b.Storages[0].Addition {
+		t.Errorf("storage = %+v", s)
+	}
+	if got.Settings["site_title"] != "OpenList" {
+		t.Errorf("settings = %v", got.Settings)
+	}
+}
+
+func TestRedactSettings(t *testing.T) {
+	settings := map[string]string{conf.Token: "tk", conf.LdapManagerPassword: "", conf.SiteTitle: "OpenList"}
+	redactSettings(settings)
+	if settings[conf.Token] != Redacted || settings[conf.LdapManagerPassword] != "" || settings[conf.SiteTitle] != "OpenList" {
+		t.Errorf("settings = %v", settings)
+	}
+}
+
+func TestImportReplaceUserRows(t *testing.T) {
+	dB := db.GetDb()
+	users := []model.User{
+		{ID: 1, Username: "admin", Role: model.ADMIN, OtpSecret: "otp"},
+		{ID: 2, Username: "guest", Role: model.GUEST},
+		{ID: 3, Username: "bob", Role: model.GENERAL},
+		{ID: 4, Username: "carol", Role: model.GENERAL},
+	}
+	for i := range users {
+		if err := dB.Create(&users[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, row := range []any{
+		&model.APIToken{UserID: 3, Prefix: "bob", TokenHash: "bob"},
+		&model.APIToken{UserID: 4, Prefix: "carol", TokenHash: "carol"},
+		&model.S3Key{UserID: 3, AccessKeyID: "bob"},
+		&model.S3Key{UserID: 4, AccessKeyID: "carol"},
+		&model.UserUsage{UserID: 3, UsedBytes: 30},
+		&model.UserUsage{UserID: 4, UsedBytes: 40},
+	} {
+		if err := dB.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := Export(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Users[0].OtpSecret != Redacted {
+		t.Errorf("otp secret = %s", b.Users[0].OtpSecret)
+	}
+	// carol is gone and the ids of bob and dave collide with the old ones
+	b.Users = []model.BundleUser{b.Users[0], b.Users[1],
+		{User: model.User{ID: 3, Username: "dave", Role: model.GENERAL}},
+		{User: model.User{ID: 4, Username: "bob", Role: model.GENERAL}},
+	}
+	if _, err = Import(b, true); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []model.APIToken
+	dB.Find(&tokens)
+	if len(tokens) != 1 || tokens[0].Prefix != "bob" || tokens[0].UserID != 4 {
+		t.Errorf("tokens = %+v", tokens)
+	}
+	var keys []model.S3Key
+	dB.Find(&keys)
+	if len(keys) != 1 || keys[0].AccessKeyID != "bob" || keys[0].UserID != 4 {
+		t.Errorf("s3 keys = %+v", keys)
+	}
+	var usages []model.UserUsage
+	dB.Find(&usages)
+	if len(usages) != 1 || usages[0].UserID != 4 || usages[0].UsedBytes != 30 {
+		t.Errorf("usages = %+v", usages)
+	}
+	admin, err := db.GetUserByName("admin")
+	if err != nil || admin.OtpSecret != "otp" {
+		t.Errorf("admin = %+v, %v", admin, err)
+	}
+}
@@ -0,0 +1,82 @@
+package bundle
+
+import (
+	"encoding/json"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// FormatOf returns the format of the bundle file by its extension, json by default
+func FormatOf(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// Marshal encodes the bundle, the yaml keys are the same as the json keys
+func Marshal(b *model.Bundle, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if format != FormatYAML {
+		return data, nil
+	}
+	var v any
+	if err = json.Unmarshal(data, &v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	data, err = yaml.Marshal(integers(v))
+	return data, errors.WithStack(err)
+}
+
+func Unmarshal(data []byte, format string) (*model.Bundle, error) {
+	if format == FormatYAML {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	b := new(model.Bundle)
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return b, nil
+}
+
+// integers converts the integral json numbers to int64,
+// or yaml would write the large ones in the exponent form which can't be decoded to integers
+func integers(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = integers(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = integers(e)
+		}
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return int64(t)
+		}
+	}
+	return v
+}
@@ -73,9 +73,15 @@ type TasksConfig struct {
 	Move               TaskConfig `json:"move" envPrefix:"MOVE_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
+	Compress           TaskConfig `json:"compress" envPrefix:"COMPRESS_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
+type Metrics struct {
+	Enable bool   `json:"enable" env:"ENABLE"`
+	Token  string `json:"token" env:"TOKEN"`
+}
+
 type Cors struct {
 	AllowOrigins []string `json:"allow_origins" env:"ALLOW_ORIGINS"`
 	AllowMethods []string `json:"allow_methods" env:"ALLOW_METHODS"`
@@ -130,8 +136,11 @@ type Config struct {
 	S3                    S3          `json:"s3" envPrefix:"S3_"`
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
+	Metrics               Metrics     `json:"metrics" envPrefix:"METRICS_"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 	ProxyAddress          string      `json:"proxy_address" env:"PROXY_ADDRESS"`
+	// InstanceID identifies the server among the ones sharing the database
+	InstanceID string `json:"instance_id" env:"INSTANCE_ID"`
 }
 
 func DefaultConfig(dataDir string) *Config {
@@ -216,6 +225,11 @@ func DefaultConfig(dataDir string) *Config {
 				Workers:  5,
 				MaxRetry: 2,
 			},
+			Compress: TaskConfig{
+				Workers:  5,
+				MaxRetry: 2,
+				// TaskPersistant: true,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
@@ -244,7 +258,12 @@ func DefaultConfig(dataDir string) *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		Metrics: Metrics{
+			Enable: false,
+			Token:  "",
+		},
 		LastLaunchedVersion: "",
 		ProxyAddress:        "",
+		InstanceID:          random.String(16),
 	}
 }
@@ -59,12 +59,28 @@ const (
 	HandleHookAfterWriting  = "handle_hook_after_writing"
 	HandleHookRateLimit     = "handle_hook_rate_limit"
 	IgnoreSystemFiles       = "ignore_system_files"
+	AuditLogEnabled         = "audit_log_enabled"
+	DefaultUserQuota        = "default_user_quota"
+	DefaultUserMaxFileSize  = "default_user_max_file_size"
+	DefaultGuestQuota       = "default_guest_quota"
+	DefaultGuestMaxFileSize = "default_guest_max_file_size"
+	TrashRetentionDays      = "trash_retention_days"
+	StorageHealthInterval   = "storage_health_interval"
+	StorageHealthThreshold  = "storage_health_threshold"
+	StorageHealthReload     = "storage_health_reload"
+	ShareAccessLogEnabled   = "share_access_log_enabled"
+	ShareAccessLogDays      = "share_access_log_days"
+	ShareAccessLogMax       = "share_access_log_max"
+	WebhookDeliveryLogDays  = "webhook_delivery_log_days"
+	WebhookDeliveryLogMax   = "webhook_delivery_log_max"
 
 	// index
-	SearchIndex     = "search_index"
-	AutoUpdateIndex = "auto_update_index"
-	IgnorePaths     = "ignore_paths"
-	MaxIndexDepth   = "max_index_depth"
+	SearchIndex          = "search_index"
+	AutoUpdateIndex      = "auto_update_index"
+	IgnorePaths          = "ignore_paths"
+	MaxIndexDepth        = "max_index_depth"
+	SearchContent        = "search_content"
+	SearchContentMaxSize = "search_content_max_size"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -92,9 +108,15 @@ const (
 	// thunder_browser
 	ThunderBrowserTempDir = "thunder_browser_temp_dir"
 
+	// yt-dlp
+	YtDlpPath   = "ytdlp_path"
+	YtDlpFormat = "ytdlp_format"
+	YtDlpArgs   = "ytdlp_args"
+
 	// single
-	Token         = "token"
-	IndexProgress = "index_progress"
+	Token                = "token"
+	IndexProgress        = "index_progress"
+	S3PermissionsGranted = "s3_permissions_granted"
 
 	// SSO
 	SSOClientId          = "sso_client_id"
@@ -154,6 +176,7 @@ const (
 	TaskMoveThreadsNum                    = "move_task_threads_num"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
+	TaskCompressThreadsNum                = "compress_task_threads_num"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
@@ -188,4 +211,10 @@ const (
 	PathKey
 	SharingIDKey
 	SkipHookKey
+	ProtocolKey
+	APITokenKey
+	// NoOverwriteKey makes op.Put fail with errs.ObjectAlreadyExists instead of overwriting a file
+	NoOverwriteKey
+	// TrashAccessKey marks the moves into and out of the trash, they are not emitted as moves
+	TrashAccessKey
 )
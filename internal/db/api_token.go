@@ -0,0 +1,65 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) (tokens []model.APIToken, count int64, err error) {
+	tokenDB := db.Model(&model.APIToken{}).Where(fmt.Sprintf("%s = ?", columnName("user_id")), userId)
+	if err = tokenDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's tokens count")
+	}
+	if err = tokenDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's tokens")
+	}
+	return tokens, count, nil
+}
+
+func GetAPITokenById(id uint) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get token")
+	}
+	return &t, nil
+}
+
+func GetAPITokenByHash(hash string) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("token_hash")), hash).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get token")
+	}
+	return &t, nil
+}
+
+func GetAPITokenByPrefix(prefix string) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("prefix")), prefix).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get token")
+	}
+	return &t, nil
+}
+
+func CreateAPIToken(t *model.APIToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateAPITokenLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.APIToken{}).Where(fmt.Sprintf("%s = ?", columnName("id")), id).
+		Update("last_used_at", t).Error)
+}
+
+// GrantAPITokensPermission adds the bits to the permission of the user's tokens having exactly the permission
+func GrantAPITokensPermission(userId uint, permission, bits int32) error {
+	return errors.WithStack(db.Model(&model.APIToken{}).
+		Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("user_id"), columnName("permission")), userId, permission).
+		Update("permission", gorm.Expr(fmt.Sprintf("%s | ?", columnName("permission")), bits)).Error)
+}
+
+func DeleteAPITokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.APIToken{}, id).Error)
+}
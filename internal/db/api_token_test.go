@@ -0,0 +1,31 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestGrantAPITokensPermission(t *testing.T) {
+	full := &model.APIToken{UserID: 10, Prefix: "grant-full", TokenHash: "grant-full", Permission: 0x71FF}
+	scoped := &model.APIToken{UserID: 10, Prefix: "grant-scoped", TokenHash: "grant-scoped", Permission: 0x1}
+	other := &model.APIToken{UserID: 11, Prefix: "grant-other", TokenHash: "grant-other", Permission: 0x71FF}
+	for _, token := range []*model.APIToken{full, scoped, other} {
+		if err := db.CreateAPIToken(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.GrantAPITokensPermission(10, 0x71FF, 0x18000); err != nil {
+		t.Fatal(err)
+	}
+	for token, want := range map[*model.APIToken]int32{full: 0x1F1FF, scoped: 0x1, other: 0x71FF} {
+		got, err := db.GetAPITokenById(token.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Permission != want {
+			t.Errorf("token %s: permission = %#x, want %#x", token.Prefix, got.Permission, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func filterAuditLogs(f model.AuditLogFilter) *gorm.DB {
+	logDB := db.Model(&model.AuditLog{})
+	if f.Username != "" {
+		logDB = logDB.Where(fmt.Sprintf("%s = ?", columnName("username")), f.Username)
+	}
+	if f.Operation != "" {
+		logDB = logDB.Where(fmt.Sprintf("%s = ?", columnName("operation")), f.Operation)
+	}
+	if f.Protocol != "" {
+		logDB = logDB.Where(fmt.Sprintf("%s = ?", columnName("protocol")), f.Protocol)
+	}
+	if f.Path != "" {
+		// '!' is used as the escape char because backslash needs escaping itself in MySQL
+		prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(f.Path) + "%"
+		logDB = logDB.Where(fmt.Sprintf(`(%s LIKE ? ESCAPE '!' OR %s LIKE ? ESCAPE '!')`,
+			columnName("src_path"), columnName("dst_path")), prefix, prefix)
+	}
+	if f.Success != nil {
+		logDB = logDB.Where(fmt.Sprintf("%s = ?", columnName("success")), *f.Success)
+	}
+	if !f.Start.IsZero() {
+		logDB = logDB.Where(fmt.Sprintf("%s >= ?", columnName("created_at")), f.Start)
+	}
+	if !f.End.IsZero() {
+		logDB = logDB.Where(fmt.Sprintf("%s < ?", columnName("created_at")), f.End)
+	}
+	return logDB
+}
+
+func GetAuditLogs(f model.AuditLogFilter, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	if err := filterAuditLogs(f).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err := filterAuditLogs(f).Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// IterAuditLogs calls fn with the matched logs in batches, from the oldest to the newest
+func IterAuditLogs(f model.AuditLogFilter, batchSize int, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	res := filterAuditLogs(f).FindInBatches(&logs, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	})
+	return errors.WithStack(res.Error)
+}
+
+func DeleteAuditLogsBefore(t time.Time) (int64, error) {
+	res := db.Where(fmt.Sprintf("%s < ?", columnName("created_at")), t).Delete(&model.AuditLog{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
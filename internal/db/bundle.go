@@ -0,0 +1,247 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// LoadBundle reads the whole configuration, the confidential fields are not redacted
+func LoadBundle() (*model.Bundle, error) {
+	b := &model.Bundle{Version: model.BundleVersion, ExportedAt: time.Now()}
+	if err := addStorageOrder(db).Find(&b.Storages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get storages")
+	}
+	if err := db.Order(columnName("path")).Find(&b.Metas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get metas")
+	}
+	var users []model.User
+	if err := db.Order(columnName("id")).Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get users")
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+		bu := model.BundleUser{User: u, PwdHash: u.PwdHash, PwdTS: u.PwdTS, Salt: u.Salt, OtpSecret: u.OtpSecret, Authn: u.Authn}
+		bu.User.Password = ""
+		b.Users = append(b.Users, bu)
+	}
+	var items []model.SettingItem
+	if err := db.Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get settings")
+	}
+	b.Settings = make(map[string]string, len(items))
+	for _, item := range items {
+		if item.Key != conf.VERSION && !item.IsDeprecated() {
+			b.Settings[item.Key] = item.Value
+		}
+	}
+	var sharings []model.SharingDB
+	if err := db.Order(columnName("id")).Find(&sharings).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sharings")
+	}
+	for _, s := range sharings {
+		b.Sharings = append(b.Sharings, model.BundleSharing{SharingDB: s, FilesRaw: s.FilesRaw, Creator: names[s.CreatorId],
+			PwdHash: s.PwdHash, Salt: s.Salt})
+	}
+	var keys []model.SSHPublicKey
+	if err := db.Order(columnName("id")).Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ssh keys")
+	}
+	for _, k := range keys {
+		b.SSHKeys = append(b.SSHKeys, model.BundleSSHKey{SSHPublicKey: k, KeyStr: k.KeyStr, Username: names[k.UserId]})
+	}
+	return b, nil
+}
+
+// findBy loads the first row of which the column equals the value into dst and reports whether it exists
+func findBy(tx *gorm.DB, dst any, column string, value any) (bool, error) {
+	res := tx.Where(fmt.Sprintf("%s = ?", columnName(column)), value).Limit(1).Find(dst)
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
+
+// ImportBundle saves the bundle atomically. In the merge mode the storages, metas, users and sharings
+// are matched by the mount path, path, username and id and are updated if exist, otherwise all of them
+// and the ssh keys are deleted before the import. The settings not in the bundle are kept in both modes.
+// In the replace mode the api tokens, s3 keys and usages of the users are moved to the imported users
+// of the same usernames, and are deleted if there is none. The trash items refer to the usernames already.
+func ImportBundle(b *model.Bundle, replace bool) (*model.BundleImportResult, error) {
+	res := &model.BundleImportResult{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var oldUsers []model.User
+		if replace {
+			if err := tx.Select("id", "username").Find(&oldUsers).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			for _, m := range []any{new(model.Storage), new(model.Meta), new(model.SharingDB), new(model.SSHPublicKey), new(model.User)} {
+				if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(m).Error; err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+		for _, s := range b.Storages {
+			var old model.Storage
+			if err := saveImported(tx, replace, &s, &s.ID, &old, &old.ID, "mount_path", s.MountPath); err != nil {
+				return errors.WithMessagef(err, "failed import storage [%s]", s.MountPath)
+			}
+			res.Storages++
+		}
+		for _, m := range b.Metas {
+			var old model.Meta
+			if err := saveImported(tx, replace, &m, &m.ID, &old, &old.ID, "path", m.Path); err != nil {
+				return errors.WithMessagef(err, "failed import meta [%s]", m.Path)
+			}
+			res.Metas++
+		}
+		ids := make(map[string]uint)
+		for _, bu := range b.Users {
+			u := bu.User
+			u.PwdHash, u.PwdTS, u.Salt, u.OtpSecret, u.Authn = bu.PwdHash, bu.PwdTS, bu.Salt, bu.OtpSecret, bu.Authn
+			u.Password = ""
+			var old model.User
+			if err := saveImported(tx, replace, &u, &u.ID, &old, &old.ID, "username", u.Username); err != nil {
+				return errors.WithMessagef(err, "failed import user [%s]", u.Username)
+			}
+			ids[u.Username] = u.ID
+			res.Users++
+		}
+		if replace {
+			remap := make(map[uint]uint, len(oldUsers))
+			for _, u := range oldUsers {
+				if id, ok := ids[u.Username]; ok {
+					remap[u.ID] = id
+				}
+			}
+			if err := remapUserRows(tx, remap, func(t *model.APIToken) *uint { return &t.UserID }); err != nil {
+				return errors.WithMessage(err, "failed remap api tokens")
+			}
+			if err := remapUserRows(tx, remap, func(k *model.S3Key) *uint { return &k.UserID }); err != nil {
+				return errors.WithMessage(err, "failed remap s3 keys")
+			}
+			if err := remapUserRows(tx, remap, func(u *model.UserUsage) *uint { return &u.UserID }); err != nil {
+				return errors.WithMessage(err, "failed remap user usages")
+			}
+		}
+		var items []model.SettingItem
+		if err := tx.Find(&items).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		for _, item := range items {
+			value, ok := b.Settings[item.Key]
+			if !ok || item.Key == conf.VERSION {
+				continue
+			}
+			if err := tx.Model(&item).Update("value", value).Error; err != nil {
+				return errors.Wrapf(err, "failed import setting [%s]", item.Key)
+			}
+			res.Settings++
+		}
+		// the users not in the bundle are kept in the merge mode
+		var users []model.User
+		if err := tx.Select("id", "username").Find(&users).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		for _, u := range users {
+			ids[u.Username] = u.ID
+		}
+		for _, bs := range b.Sharings {
+			s := bs.SharingDB
+			creator, ok := ids[bs.Creator]
+			if !ok {
+				return errors.Errorf("the creator [%s] of sharing [%s] is not found", bs.Creator, s.ID)
+			}
+			s.FilesRaw, s.CreatorId, s.PwdHash, s.Salt = bs.FilesRaw, creator, bs.PwdHash, bs.Salt
+			if s.Pwd != "" && s.PwdHash == "" {
+				s.SetPwd(s.Pwd)
+			}
+			var old model.SharingDB
+			exists, err := findBy(tx, &old, "id", s.ID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				err = tx.Save(&s).Error
+			} else {
+				err = tx.Create(&s).Error
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed import sharing [%s]", s.ID)
+			}
+			res.Sharings++
+		}
+		for _, bk := range b.SSHKeys {
+			k := bk.SSHPublicKey
+			userId, ok := ids[bk.Username]
+			if !ok {
+				return errors.Errorf("the user [%s] of ssh key [%s] is not found", bk.Username, k.Title)
+			}
+			k.KeyStr, k.UserId = bk.KeyStr, userId
+			if !replace {
+				k.ID = 0
+				var n int64
+				if err := tx.Model(&model.SSHPublicKey{}).Where(fmt.Sprintf("%s = ? AND %s = ?",
+					columnName("user_id"), columnName("fingerprint")), k.UserId, k.Fingerprint).Count(&n).Error; err != nil {
+					return errors.WithStack(err)
+				}
+				if n > 0 {
+					continue
+				}
+			}
+			if err := tx.Create(&k).Error; err != nil {
+				return errors.Wrapf(err, "failed import ssh key [%s]", k.Title)
+			}
+			res.SSHKeys++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// remapUserRows changes the user ids of all rows of T by the remap, the rows of the users not in the remap are deleted.
+// The rows are created again rather than updated, so the ids swapped between two users don't collide.
+func remapUserRows[T any](tx *gorm.DB, remap map[uint]uint, userId func(*T) *uint) error {
+	var rows []T
+	if err := tx.Find(&rows).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(new(T)).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range rows {
+		id := userId(&rows[i])
+		newId, ok := remap[*id]
+		if !ok {
+			continue
+		}
+		*id = newId
+		if err := tx.Create(&rows[i]).Error; err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// saveImported creates the row with the id in the bundle in the replace mode. In the merge mode it
+// updates the row of which the column equals the value, or creates the row with a new id.
+func saveImported(tx *gorm.DB, replace bool, row any, id *uint, old any, oldId *uint, column string, value any) error {
+	if replace {
+		return errors.WithStack(tx.Create(row).Error)
+	}
+	exists, err := findBy(tx, old, column, value)
+	if err != nil {
+		return err
+	}
+	if exists {
+		*id = *oldId
+		return errors.WithStack(tx.Save(row).Error)
+	}
+	*id = 0
+	return errors.WithStack(tx.Create(row).Error)
+}
@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.AuditLog), new(model.Webhook), new(model.WebhookDelivery), new(model.UserUsage), new(model.TrashItem), new(model.APIToken), new(model.SyncJob), new(model.S3Key), new(model.IndexJob), new(model.WebdavLock), new(model.WebdavProp), new(model.ShareAccess))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetIndexJobById(id uint) (*model.IndexJob, error) {
+	var j model.IndexJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get index job")
+	}
+	return &j, nil
+}
+
+func GetIndexJobs(pageIndex, pageSize int) (jobs []model.IndexJob, count int64, err error) {
+	jobDB := db.Model(&model.IndexJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get index jobs count")
+	}
+	if err = jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find index jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetScheduledIndexJobs() ([]model.IndexJob, error) {
+	var jobs []model.IndexJob
+	if err := db.Where(fmt.Sprintf("%s = ? AND %s <> ?", columnName("disabled"), columnName("cron")), false, "").
+		Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find scheduled index jobs")
+	}
+	return jobs, nil
+}
+
+func CreateIndexJob(j *model.IndexJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateIndexJob(j *model.IndexJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+// UpdateIndexJobProgress only updates the progress columns, so it doesn't overwrite a concurrent edit of the job
+func UpdateIndexJobProgress(j *model.IndexJob) error {
+	return errors.WithStack(db.Model(j).Select("last_run_at", "progress_obj_count", "progress_is_done",
+		"progress_last_done_time", "progress_error").Updates(j).Error)
+}
+
+// FinishInterruptedIndexJobs marks the jobs interrupted by a restart as done
+func FinishInterruptedIndexJobs() error {
+	return errors.WithStack(db.Model(&model.IndexJob{}).Where(fmt.Sprintf("%s = ?", columnName("progress_is_done")), false).
+		Updates(map[string]any{"progress_is_done": true, "progress_error": "interrupted"}).Error)
+}
+
+func DeleteIndexJobById(id uint) error {
+	return errors.WithStack(db.Delete(&model.IndexJob{}, id).Error)
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetS3KeysByUserId(userId uint, pageIndex, pageSize int) (keys []model.S3Key, count int64, err error) {
+	keyDB := db.Model(&model.S3Key{}).Where(fmt.Sprintf("%s = ?", columnName("user_id")), userId)
+	if err = keyDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's s3 keys count")
+	}
+	if err = keyDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's s3 keys")
+	}
+	return keys, count, nil
+}
+
+func GetS3KeyById(id uint) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3KeyByAccessKeyId(accessKeyId string) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("access_key_id")), accessKeyId).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func CountS3Keys() (int64, error) {
+	var count int64
+	if err := db.Model(&model.S3Key{}).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed get s3 keys count")
+	}
+	return count, nil
+}
+
+func CreateS3Key(k *model.S3Key) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Key{}, id).Error)
+}
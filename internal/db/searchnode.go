@@ -4,6 +4,7 @@ import (
 	"fmt"
 	stdpath "path"
 	"strings"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -77,6 +78,7 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		isDir := req.Scope == 1
 		searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	whereSearchFilter(searchDB, req.SearchFilter)
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
@@ -89,3 +91,24 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 	}
 	return files, count, nil
 }
+
+func whereSearchFilter(searchDB *gorm.DB, f model.SearchFilter) {
+	if f.MinSize > 0 {
+		searchDB.Where(fmt.Sprintf("%s >= ?", columnName("size")), f.MinSize)
+	}
+	if f.MaxSize > 0 {
+		searchDB.Where(fmt.Sprintf("%s <= ?", columnName("size")), f.MaxSize)
+	}
+	if f.ModifiedAfter > 0 {
+		searchDB.Where(fmt.Sprintf("%s >= ?", columnName("modified")), time.Unix(f.ModifiedAfter, 0).UTC())
+	}
+	if f.ModifiedBefore > 0 {
+		searchDB.Where(fmt.Sprintf("%s <= ?", columnName("modified")), time.Unix(f.ModifiedBefore, 0).UTC())
+	}
+	if len(f.Extensions) > 0 {
+		searchDB.Where(fmt.Sprintf("%s IN ?", columnName("ext")), f.Extensions)
+	}
+	if len(f.Types) > 0 {
+		searchDB.Where(fmt.Sprintf("%s IN ?", columnName("obj_type")), f.Types)
+	}
+}
@@ -0,0 +1,71 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestSearchNodeFilter(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	nodes := []model.SearchNode{
+		{Parent: "/filter", Name: "movie.mp4", Size: 300, Modified: day(1), Ext: "mp4", ObjType: conf.VIDEO},
+		{Parent: "/filter", Name: "song.mp3", Size: 200, Modified: day(2), Ext: "mp3", ObjType: conf.AUDIO},
+		{Parent: "/filter", Name: "note.txt", Size: 100, Modified: day(3), Ext: "txt", ObjType: conf.TEXT},
+		{Parent: "/filter", Name: "photos", IsDir: true, Modified: day(4), ObjType: conf.FOLDER},
+	}
+	if err := db.BatchCreateSearchNodes(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	defer db.DeleteSearchNodesByParent("/filter")
+
+	tests := []struct {
+		name   string
+		filter model.SearchFilter
+		want   []string
+	}{
+		{"none", model.SearchFilter{}, []string{"movie.mp4", "note.txt", "photos", "song.mp3"}},
+		{"size", model.SearchFilter{MinSize: 150, MaxSize: 250}, []string{"song.mp3"}},
+		{"min size", model.SearchFilter{MinSize: 200}, []string{"movie.mp4", "song.mp3"}},
+		{"modified", model.SearchFilter{ModifiedAfter: day(2).Unix(), ModifiedBefore: day(3).Unix()}, []string{"note.txt", "song.mp3"}},
+		{"extensions", model.SearchFilter{Extensions: []string{"mp4", "txt"}}, []string{"movie.mp4", "note.txt"}},
+		{"types", model.SearchFilter{Types: []int{conf.FOLDER, conf.AUDIO}}, []string{"photos", "song.mp3"}},
+		{"combined", model.SearchFilter{MaxSize: 250, Types: []int{conf.VIDEO, conf.TEXT}}, []string{"note.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, total, err := db.SearchNode(model.SearchReq{
+				Parent:       "/filter",
+				SearchFilter: tt.filter,
+				PageReq:      model.PageReq{Page: 1, PerPage: 10},
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int(total) != len(tt.want) || len(res) != len(tt.want) {
+				t.Fatalf("got %d nodes of total %d, want %v", len(res), total, tt.want)
+			}
+			for i, node := range res {
+				if node.Name != tt.want[i] {
+					t.Errorf("got %s at %d, want %s", node.Name, i, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateShareAccess(a *model.ShareAccess) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+func whereSharing(sid string) (string, string) {
+	return fmt.Sprintf("%s = ?", columnName("sharing_id")), sid
+}
+
+func GetShareAccesses(sid string, pageIndex, pageSize int) (accesses []model.ShareAccess, count int64, err error) {
+	accessDB := db.Model(&model.ShareAccess{}).Where(whereSharing(sid))
+	if err := accessDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get share accesses count")
+	}
+	if err := accessDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&accesses).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find share accesses")
+	}
+	return accesses, count, nil
+}
+
+// GetShareAccessesSince returns the time, ip and bytes of the accesses since the time, from the oldest
+func GetShareAccessesSince(sid string, since time.Time) ([]model.ShareAccess, error) {
+	var accesses []model.ShareAccess
+	err := db.Select("created_at", "ip", "bytes").Where(whereSharing(sid)).
+		Where(fmt.Sprintf("%s >= ?", columnName("created_at")), since).
+		Order(columnName("id")).Find(&accesses).Error
+	return accesses, errors.Wrapf(err, "failed find share accesses")
+}
+
+// GetShareFileStats returns the download count and bytes of the most downloaded files since the time
+func GetShareFileStats(sid string, since time.Time, limit int) ([]model.ShareFileStat, error) {
+	var stats []model.ShareFileStat
+	err := db.Model(&model.ShareAccess{}).
+		Select(fmt.Sprintf("%s, COUNT(*) AS downloads, SUM(%s) AS bytes", columnName("path"), columnName("bytes"))).
+		Where(whereSharing(sid)).
+		Where(fmt.Sprintf("%s = ? AND %s >= ?", columnName("action"), columnName("created_at")), model.ShareAccessDownload, since).
+		Group(columnName("path")).Order("downloads DESC").Limit(limit).Scan(&stats).Error
+	return stats, errors.Wrapf(err, "failed get share file stats")
+}
+
+func DeleteShareAccessesBySharingId(sid string) error {
+	return errors.WithStack(db.Where(whereSharing(sid)).Delete(&model.ShareAccess{}).Error)
+}
+
+func DeleteShareAccessesBefore(t time.Time) (int64, error) {
+	res := db.Where(fmt.Sprintf("%s < ?", columnName("created_at")), t).Delete(&model.ShareAccess{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
+
+// TrimShareAccesses deletes the oldest accesses of the sharings having more than max accesses
+func TrimShareAccesses(max int) (int64, error) {
+	var over []struct {
+		SharingID string
+		Count     int
+	}
+	if err := db.Model(&model.ShareAccess{}).Select(fmt.Sprintf("%s AS sharing_id, COUNT(*) AS count", columnName("sharing_id"))).
+		Group(columnName("sharing_id")).Having("COUNT(*) > ?", max).Scan(&over).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count share accesses")
+	}
+	var deleted int64
+	for _, o := range over {
+		// the id of the newest access to delete
+		var last model.ShareAccess
+		if err := db.Select("id").Where(whereSharing(o.SharingID)).Order(columnName("id")).
+			Offset(o.Count - max - 1).Limit(1).Find(&last).Error; err != nil {
+			return deleted, errors.WithStack(err)
+		}
+		res := db.Where(whereSharing(o.SharingID)).Where(fmt.Sprintf("%s <= ?", columnName("id")), last.ID).Delete(&model.ShareAccess{})
+		if res.Error != nil {
+			return deleted, errors.WithStack(res.Error)
+		}
+		deleted += res.RowsAffected
+	}
+	return deleted, nil
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"fmt"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func GetSharingById(id string) (*model.SharingDB, error) {
@@ -25,6 +28,15 @@ func GetSharings(pageIndex, pageSize int) (sharings []model.SharingDB, count int
 	return sharings, count, nil
 }
 
+// GetSharingsWithPlainPwd returns the sharings of which the share code is not hashed yet
+func GetSharingsWithPlainPwd() (sharings []model.SharingDB, err error) {
+	if err = db.Where(fmt.Sprintf("%s <> ? AND %s = ?", columnName("pwd"), columnName("pwd_hash")), "", "").
+		Find(&sharings).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sharings")
+	}
+	return sharings, nil
+}
+
 func GetSharingsByCreatorId(creator uint, pageIndex, pageSize int) (sharings []model.SharingDB, count int64, err error) {
 	sharingDB := db.Model(&model.SharingDB{})
 	cond := model.SharingDB{CreatorId: creator}
@@ -64,11 +76,25 @@ func UpdateSharing(s *model.SharingDB) error {
 	return errors.WithStack(db.Save(s).Error)
 }
 
+func AddSharingBytesServed(id string, n int64) error {
+	return errors.WithStack(db.Model(&model.SharingDB{ID: id}).
+		UpdateColumn("bytes_served", gorm.Expr(columnName("bytes_served")+" + ?", n)).Error)
+}
+
+// DisableSharing only updates the disabled column, so the bytes served meanwhile are kept
+func DisableSharing(id string) error {
+	return errors.WithStack(db.Model(&model.SharingDB{ID: id}).UpdateColumn("disabled", true).Error)
+}
+
 func DeleteSharingById(id string) error {
 	s := model.SharingDB{ID: id}
 	return errors.WithStack(db.Where(s).Delete(&s).Error)
 }
 
 func DeleteSharingsByCreatorId(creatorId uint) error {
+	sharings := db.Model(&model.SharingDB{}).Select("id").Where("creator_id = ?", creatorId)
+	if err := db.Where(fmt.Sprintf("%s IN (?)", columnName("sharing_id")), sharings).Delete(&model.ShareAccess{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
 	return errors.WithStack(db.Where("creator_id = ?", creatorId).Delete(&model.SharingDB{}).Error)
 }
@@ -0,0 +1,33 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestGetSharingsWithPlainPwd(t *testing.T) {
+	for _, s := range []*model.SharingDB{
+		{ID: "plain", Pwd: "code"},
+		(&model.SharingDB{ID: "hashed"}).SetPwd("code"),
+		{ID: "no-pwd"},
+	} {
+		if _, err := db.CreateSharing(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sharings, err := db.GetSharingsWithPlainPwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sharings) != 1 || sharings[0].ID != "plain" {
+		t.Fatalf("got %+v, want the plain sharing only", sharings)
+	}
+	if err = db.UpdateSharing(sharings[0].SetPwd(sharings[0].Pwd)); err != nil {
+		t.Fatal(err)
+	}
+	if sharings, err = db.GetSharingsWithPlainPwd(); err != nil || len(sharings) != 0 {
+		t.Errorf("the hashed share code is still plain: %+v, %v", sharings, err)
+	}
+}
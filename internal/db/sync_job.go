@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &j, nil
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err = jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetScheduledSyncJobs() ([]model.SyncJob, error) {
+	var jobs []model.SyncJob
+	if err := db.Where(fmt.Sprintf("%s = ? AND %s <> ?", columnName("disabled"), columnName("cron")), false, "").
+		Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find scheduled sync jobs")
+	}
+	return jobs, nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+// UpdateSyncJobResult only updates the result columns, so it doesn't overwrite a concurrent edit of the job
+func UpdateSyncJobResult(j *model.SyncJob) error {
+	return errors.WithStack(db.Model(j).Select("last_run_at", "last_result").Updates(j).Error)
+}
+
+func DeleteSyncJobById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
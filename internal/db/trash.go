@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(item *model.TrashItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func GetTrashItemById(id string) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("id")), id).First(&item).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+func GetTrashItems(f model.TrashItemFilter, pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	if f.Username != "" {
+		trashDB = trashDB.Where(fmt.Sprintf("%s = ?", columnName("username")), f.Username)
+	}
+	if f.Path != "" {
+		prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(f.Path) + "%"
+		trashDB = trashDB.Where(fmt.Sprintf(`%s LIKE ? ESCAPE '!'`, columnName("path")), prefix)
+	}
+	if err = trashDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err = trashDB.Order(columnName("removed_at") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+// GetTrashItemsBefore returns the items removed before t
+func GetTrashItemsBefore(t time.Time) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	if err := db.Where(fmt.Sprintf("%s < ?", columnName("removed_at")), t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("id")), id).Delete(&model.TrashItem{}).Error)
+}
@@ -2,11 +2,14 @@ package db
 
 import (
 	"encoding/base64"
+	"fmt"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetUserByRole(role int) (*model.User, error) {
@@ -61,9 +64,64 @@ func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err err
 }
 
 func DeleteUserById(id uint) error {
+	if err := db.Delete(&model.UserUsage{}, id).Error; err != nil {
+		return errors.Wrapf(err, "failed delete user usage")
+	}
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("user_id")), id).Delete(&model.APIToken{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete user tokens")
+	}
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("user_id")), id).Delete(&model.S3Key{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete user s3 keys")
+	}
 	return errors.WithStack(db.Delete(&model.User{}, id).Error)
 }
 
+func GetUserUsedBytes(id uint) (int64, error) {
+	var usage model.UserUsage
+	if err := db.Where(model.UserUsage{UserID: id}).Limit(1).Find(&usage).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed get user usage")
+	}
+	return usage.UsedBytes, nil
+}
+
+func initUserUsage(id uint) error {
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserUsage{UserID: id}).Error
+	return errors.Wrapf(err, "failed init user usage")
+}
+
+// AddUserUsedBytes increases the used bytes of the user atomically
+func AddUserUsedBytes(id uint, n int64) error {
+	if err := initUserUsage(id); err != nil {
+		return err
+	}
+	return errors.WithStack(db.Model(&model.UserUsage{UserID: id}).
+		Update("used_bytes", gorm.Expr(columnName("used_bytes")+" + ?", n)).Error)
+}
+
+// ReserveUserUsedBytes increases the used bytes of the user by n only if they don't exceed the limit then,
+// the check and the increase are a single update, it reports whether the bytes are reserved
+func ReserveUserUsedBytes(id uint, n, limit int64) (bool, error) {
+	if err := initUserUsage(id); err != nil {
+		return false, err
+	}
+	used := columnName("used_bytes")
+	res := db.Model(&model.UserUsage{}).
+		Where(fmt.Sprintf("%s = ? AND %s + ? <= ?", columnName("user_id"), used), id, n, limit).
+		Update("used_bytes", gorm.Expr(used+" + ?", n))
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
+
+// SubUserUsedBytes decreases the used bytes of the user atomically, they never go below 0
+func SubUserUsedBytes(id uint, n int64) error {
+	used := columnName("used_bytes")
+	return errors.WithStack(db.Model(&model.UserUsage{UserID: id}).
+		Update("used_bytes", gorm.Expr(fmt.Sprintf("CASE WHEN %s > ? THEN %s - ? ELSE 0 END", used, used), n, n)).Error)
+}
+
+func ResetUserUsedBytes(id uint) error {
+	return errors.WithStack(db.Delete(&model.UserUsage{}, id).Error)
+}
+
 func UpdateAuthn(userID uint, authn string) error {
 	return db.Model(&model.User{ID: userID}).Update("authn", authn).Error
 }
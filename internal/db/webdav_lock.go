@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webdavLockSentinel is the token of the row updated first by CreateWebdavLock, so the creations
+// are serialized by the row lock of the database across the instances
+const webdavLockSentinel = "sentinel"
+
+func GetWebdavLocks(pageIndex, pageSize int) (locks []model.WebdavLock, count int64, err error) {
+	lockDB := db.Model(&model.WebdavLock{}).Where(fmt.Sprintf("%s <> ?", columnName("token")), webdavLockSentinel)
+	if err = lockDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webdav locks count")
+	}
+	if err = lockDB.Order(columnName("created_at")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&locks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webdav locks")
+	}
+	return locks, count, nil
+}
+
+func GetWebdavLockByToken(token string) (*model.WebdavLock, error) {
+	if token == webdavLockSentinel {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "failed get webdav lock")
+	}
+	var l model.WebdavLock
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).First(&l).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav lock")
+	}
+	return &l, nil
+}
+
+// whereRootRelated matches the locks of which the root is the root, an ancestor or a descendant of it
+func whereRootRelated(tx *gorm.DB, root string) *gorm.DB {
+	if root == "/" {
+		return tx.Where(fmt.Sprintf("%s <> ?", columnName("token")), webdavLockSentinel)
+	}
+	ancestors := []string{"/"}
+	for i := 1; i < len(root); i++ {
+		if root[i] == '/' {
+			ancestors = append(ancestors, root[:i])
+		}
+	}
+	ancestors = append(ancestors, root)
+	prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(root) + "/%"
+	return tx.Where(fmt.Sprintf(`%s IN ? OR %s LIKE ? ESCAPE '!'`, columnName("root"), columnName("root")), ancestors, prefix)
+}
+
+// CreateWebdavLock creates the lock in a transaction if conflict passes for none of the existing locks
+// of which the root is related to the root of l
+func CreateWebdavLock(l *model.WebdavLock, conflict func(old *model.WebdavLock) bool) (created bool, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// the transaction doesn't lock the conflicting rows not existing yet, so the sentinel row is locked instead
+		sentinel := &model.WebdavLock{Token: webdavLockSentinel}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(sentinel).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if err := tx.Model(sentinel).Update("created_at", time.Now()).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		var locks []model.WebdavLock
+		if err := whereRootRelated(tx, l.Root).Find(&locks).Error; err != nil {
+			return errors.Wrapf(err, "failed find webdav locks")
+		}
+		for i := range locks {
+			if conflict(&locks[i]) {
+				return nil
+			}
+		}
+		if err := tx.Create(l).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func UpdateWebdavLockTimeout(l *model.WebdavLock) error {
+	return errors.WithStack(db.Model(l).Select("timeout", "expires_at").Updates(l).Error)
+}
+
+func DeleteWebdavLockByToken(token string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).Delete(&model.WebdavLock{}).Error)
+}
+
+// DeleteTemporaryWebdavLocks deletes the temporary locks taken by the instance,
+// and the ones taken before the locks recorded their instances
+func DeleteTemporaryWebdavLocks(instance string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ? AND %s IN ?", columnName("temporary"), columnName("instance")),
+		true, []string{instance, ""}).Delete(&model.WebdavLock{}).Error)
+}
+
+func DeleteExpiredWebdavLocks(now time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("expires_at")), now).Delete(&model.WebdavLock{}).Error)
+}
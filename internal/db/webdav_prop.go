@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// wherePathUnder matches the props of the path and its descendants
+func wherePathUnder(tx *gorm.DB, path string) *gorm.DB {
+	if path == "/" {
+		return tx.Where("1 = 1")
+	}
+	prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(path) + "/%"
+	return tx.Where(fmt.Sprintf(`%s = ? OR %s LIKE ? ESCAPE '!'`, columnName("path"), columnName("path")), path, prefix)
+}
+
+// GetWebdavPropsUnder returns the props of the path and its descendants up to depth levels below it,
+// a negative depth means all of the descendants
+func GetWebdavPropsUnder(path string, depth int) ([]model.WebdavProp, error) {
+	var props []model.WebdavProp
+	tx := db
+	switch depth {
+	case 0:
+		tx = tx.Where(fmt.Sprintf("%s = ?", columnName("path")), path)
+	case 1:
+		prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.TrimSuffix(path, "/")) + "/%"
+		tx = tx.Where(fmt.Sprintf(`%s = ? OR (%s LIKE ? ESCAPE '!' AND %s NOT LIKE ? ESCAPE '!')`,
+			columnName("path"), columnName("path"), columnName("path")), path, prefix, prefix+"/%")
+	default:
+		tx = wherePathUnder(tx, path)
+	}
+	if err := tx.Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webdav props")
+	}
+	return props, nil
+}
+
+// PatchWebdavProps removes the props of the names in remove, then sets the props in set atomically
+func PatchWebdavProps(path string, set, remove []model.WebdavProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range append(remove, set...) {
+			if err := tx.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ?",
+				columnName("path"), columnName("space"), columnName("local")), path, p.Space, p.Local).
+				Delete(&model.WebdavProp{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range set {
+			set[i].ID, set[i].Path = 0, path
+			if err := tx.Create(&set[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// MoveWebdavProps moves the props of the path and its descendants to the new path,
+// the props left at the new path are replaced
+func MoveWebdavProps(srcPath, dstPath string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := wherePathUnder(tx, dstPath).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		var props []model.WebdavProp
+		if err := wherePathUnder(tx, srcPath).Find(&props).Error; err != nil {
+			return err
+		}
+		for _, p := range props {
+			newPath := dstPath + strings.TrimPrefix(p.Path, srcPath)
+			if err := tx.Model(&p).Update("path", newPath).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// DeleteWebdavProps deletes the props of the path and its descendants
+func DeleteWebdavProps(path string) error {
+	return errors.WithStack(wherePathUnder(db, path).Delete(&model.WebdavProp{}).Error)
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var w model.Webhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &w, nil
+}
+
+func GetWebhooks(pageIndex, pageSize int) (webhooks []model.Webhook, count int64, err error) {
+	webhookDB := db.Model(&model.Webhook{})
+	if err = webhookDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhooks count")
+	}
+	if err = webhookDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&webhooks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhooks")
+	}
+	return webhooks, count, nil
+}
+
+func GetEnabledWebhooks() ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("disabled")), false).Find(&webhooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled webhooks")
+	}
+	return webhooks, nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func DeleteWebhookById(id uint) error {
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("webhook_id")), id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete webhook deliveries")
+	}
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
+
+func CreateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Create(d).Error)
+}
+
+func UpdateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Save(d).Error)
+}
+
+func GetWebhookDeliveries(webhookId uint, pageIndex, pageSize int) (deliveries []model.WebhookDelivery, count int64, err error) {
+	deliveryDB := db.Model(&model.WebhookDelivery{})
+	cond := fmt.Sprintf("%s = ?", columnName("webhook_id"))
+	if err = deliveryDB.Where(cond, webhookId).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhook deliveries count")
+	}
+	if err = deliveryDB.Where(cond, webhookId).Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhook deliveries")
+	}
+	return deliveries, count, nil
+}
+
+func DeleteWebhookDeliveriesBefore(t time.Time) (int64, error) {
+	res := db.Where(fmt.Sprintf("%s < ?", columnName("created_at")), t).Delete(&model.WebhookDelivery{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
+
+// TrimWebhookDeliveries deletes the oldest deliveries of the webhooks having more than max deliveries
+func TrimWebhookDeliveries(max int) (int64, error) {
+	var over []struct {
+		WebhookID uint
+		Count     int
+	}
+	if err := db.Model(&model.WebhookDelivery{}).Select(fmt.Sprintf("%s AS webhook_id, COUNT(*) AS count", columnName("webhook_id"))).
+		Group(columnName("webhook_id")).Having("COUNT(*) > ?", max).Scan(&over).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count webhook deliveries")
+	}
+	cond := fmt.Sprintf("%s = ?", columnName("webhook_id"))
+	var deleted int64
+	for _, o := range over {
+		// the id of the newest delivery to delete
+		var last model.WebhookDelivery
+		if err := db.Select("id").Where(cond, o.WebhookID).Order(columnName("id")).
+			Offset(o.Count - max - 1).Limit(1).Find(&last).Error; err != nil {
+			return deleted, errors.WithStack(err)
+		}
+		res := db.Where(cond, o.WebhookID).Where(fmt.Sprintf("%s <= ?", columnName("id")), last.ID).Delete(&model.WebhookDelivery{})
+		if res.Error != nil {
+			return deleted, errors.WithStack(res.Error)
+		}
+		deleted += res.RowsAffected
+	}
+	return deleted, nil
+}
@@ -0,0 +1,47 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestTrimWebhookDeliveries(t *testing.T) {
+	for _, n := range []struct {
+		webhookID uint
+		count     int
+	}{{1, 5}, {2, 2}} {
+		for i := 0; i < n.count; i++ {
+			if err := db.CreateWebhookDelivery(&model.WebhookDelivery{WebhookID: n.webhookID, Event: "fs.upload"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	newest, _, err := db.GetWebhookDeliveries(1, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trimmed, err := db.TrimWebhookDeliveries(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trimmed != 2 {
+		t.Errorf("expect 2 trimmed, got %d", trimmed)
+	}
+	kept, count, err := db.GetWebhookDeliveries(1, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || len(kept) != 3 {
+		t.Fatalf("expect 3 deliveries kept, got %d", count)
+	}
+	for i := range kept {
+		if kept[i].ID != newest[i].ID {
+			t.Errorf("expect the newest deliveries kept, got %d at %d", kept[i].ID, i)
+		}
+	}
+	if _, count, _ = db.GetWebhookDeliveries(2, 1, 10); count != 2 {
+		t.Errorf("the deliveries under the limit should be kept, got %d", count)
+	}
+}
@@ -24,8 +24,13 @@ var (
 	DriverExtractNotSupported = errors.New("driver extraction not supported")
 
 	WrongShareCode  = errors.New("wrong share code")
+	SharingLocked   = errors.New("too many wrong share codes, try again later")
 	InvalidSharing  = errors.New("invalid sharing")
 	SharingNotFound = errors.New("sharing not found")
+	SharingHidden   = errors.New("the files of the share are hidden")
+	UploadForbidden = errors.New("upload is not allowed by the share")
+
+	SharingBytesExceeded = errors.New("the share has reached its download limit")
 )
 
 // NewErr wrap constant error with an extra message
@@ -44,3 +49,7 @@ func IsNotSupportError(err error) bool {
 func IsNotImplementError(err error) bool {
 	return errors.Is(pkgerr.Cause(err), NotImplement)
 }
+
+func IsQuotaError(err error) bool {
+	return errors.Is(pkgerr.Cause(err), QuotaExceeded) || errors.Is(pkgerr.Cause(err), FileTooLarge)
+}
@@ -12,11 +12,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
@@ -63,6 +65,14 @@ func (t *ArchiveDownloadTask) Run() error {
 	return nil
 }
 
+func (t *ArchiveDownloadTask) OnSucceeded() {
+	task.HandleStateHook(t, true)
+}
+
+func (t *ArchiveDownloadTask) OnFailed() {
+	task.HandleStateHook(t, false)
+}
+
 func (t *ArchiveDownloadTask) RunWithoutPushUploadTask() (*ArchiveContentUploadTask, error) {
 	srcObj, tool, ss, err := op.GetArchiveToolAndStream(t.Ctx(), t.SrcStorage, t.SrcActualPath, model.LinkArgs{})
 	if err != nil {
@@ -164,10 +174,12 @@ func (t *ArchiveContentUploadTask) Run() error {
 
 func (t *ArchiveContentUploadTask) OnSucceeded() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	task.HandleStateHook(t, true)
 }
 
 func (t *ArchiveContentUploadTask) OnFailed() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	task.HandleStateHook(t, false)
 }
 
 func (t *ArchiveContentUploadTask) SetRetry(retry int, maxRetry int) {
@@ -259,7 +271,7 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTask *Arch
 		}
 		fs.Closers.Add(file)
 		t.status = "uploading"
-		err = op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.dstStorage, t.DstActualPath, fs, t.SetProgress)
+		err = quota.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.dstStorage, t.DstActualPath, fs, t.SetProgress)
 		if err != nil {
 			return err
 		}
@@ -347,8 +359,215 @@ var ArchiveContentUploadTaskManager = &archiveContentUploadTaskManagerType{
 	Manager: nil,
 }
 
+type ArchiveCompressTask struct {
+	TaskData
+	model.ArchiveCompressArgs
+}
+
+func (t *ArchiveCompressTask) GetName() string {
+	return fmt.Sprintf("compress [%s](%s)%v to [%s](%s) as %s", t.SrcStorageMp, t.SrcActualPath, t.Names,
+		t.DstStorageMp, t.DstActualPath, t.ArchiveName)
+}
+
+func (t *ArchiveCompressTask) Run() error {
+	if t.SrcStorage == nil {
+		if srcStorage, _, err := op.GetStorageAndActualPath(t.SrcStorageMp); err == nil {
+			t.SrcStorage = srcStorage
+		} else {
+			return err
+		}
+		if dstStorage, _, err := op.GetStorageAndActualPath(t.DstStorageMp); err == nil {
+			t.DstStorage = dstStorage
+		} else {
+			return err
+		}
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	return t.compress()
+}
+
+type compressEntry struct {
+	actualPath string
+	name       string
+	obj        model.Obj
+}
+
+func (t *ArchiveCompressTask) OnSucceeded() {
+	task.HandleStateHook(t, true)
+}
+
+func (t *ArchiveCompressTask) OnFailed() {
+	task.HandleStateHook(t, false)
+}
+
+func (t *ArchiveCompressTask) compress() error {
+	compressor, err := tool.GetCompressor(t.Format)
+	if err != nil {
+		return err
+	}
+	if !t.Overwrite {
+		dstPath := stdpath.Join(t.DstActualPath, t.ArchiveName)
+		if res, _ := op.Get(t.Ctx(), t.DstStorage, dstPath); res != nil {
+			return errs.ObjectAlreadyExists
+		}
+	}
+	t.Status = "walking src objects"
+	var entries []compressEntry
+	var total int64
+	for _, name := range t.Names {
+		actualPath := stdpath.Join(t.SrcActualPath, name)
+		obj, err := op.Get(t.Ctx(), t.SrcStorage, actualPath)
+		if err != nil {
+			return errors.WithMessagef(err, "failed get src [%s] object", actualPath)
+		}
+		size, err := t.walk(actualPath, obj.GetName(), obj, &entries)
+		if err != nil {
+			return err
+		}
+		total += size
+	}
+	t.SetTotalBytes(total)
+
+	tempPath, err := genTempFileName("file-")
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(tempPath)
+	}()
+	w, err := compressor.NewWriter(file, t.Format, t.Password)
+	if err != nil {
+		return err
+	}
+	t.Status = "compressing"
+	compressUp := model.UpdateProgressWithRange(t.SetProgress, 0, 50)
+	var written int64
+	for _, e := range entries {
+		if err = t.Ctx().Err(); err != nil {
+			return err
+		}
+		if e.obj.IsDir() {
+			err = w.WriteDir(e.name, e.obj.ModTime())
+		} else {
+			err = t.writeFile(w, e)
+			written += e.obj.GetSize()
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed compress [%s]", e.actualPath)
+		}
+		if total > 0 {
+			compressUp(float64(written) / float64(total) * 100)
+		}
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	t.Status = "uploading"
+	fs := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     t.ArchiveName,
+			Size:     info.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype:     utils.GetMimeType(t.ArchiveName),
+		WebPutAsTask: true,
+		Reader:       file,
+	}
+	return quota.Put(t.Ctx(), t.DstStorage, t.DstActualPath, fs, model.UpdateProgressWithRange(t.SetProgress, 50, 100))
+}
+
+// walk appends obj and all its children to entries and returns their total size
+func (t *ArchiveCompressTask) walk(actualPath, name string, obj model.Obj, entries *[]compressEntry) (int64, error) {
+	*entries = append(*entries, compressEntry{actualPath: actualPath, name: name, obj: obj})
+	if !obj.IsDir() {
+		return obj.GetSize(), nil
+	}
+	objs, err := t.list(actualPath)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed list src [%s] objs", actualPath)
+	}
+	var total int64
+	for _, o := range objs {
+		if err = t.Ctx().Err(); err != nil {
+			return 0, err
+		}
+		p := stdpath.Join(actualPath, o.GetName())
+		if o.IsDir() && !t.canAccess(p) {
+			continue
+		}
+		size, err := t.walk(p, stdpath.Join(name, o.GetName()), o, entries)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// list lists with fs.List, so the hide rules of the metas and the trash of the creator are applied
+func (t *ArchiveCompressTask) list(actualPath string) ([]model.Obj, error) {
+	reqPath := stdpath.Join(t.SrcStorageMp, actualPath)
+	meta, _ := op.GetNearestMeta(reqPath)
+	return List(context.WithValue(t.Ctx(), conf.MetaKey, meta), reqPath, &ListArgs{NoLog: true})
+}
+
+// canAccess checks the hide rules and passwords of the sub folders,
+// the meta of the compressed folder has been verified before the task is created
+func (t *ArchiveCompressTask) canAccess(actualPath string) bool {
+	user, _ := t.Ctx().Value(conf.UserKey).(*model.User)
+	if user == nil {
+		return true
+	}
+	reqPath := stdpath.Join(t.SrcStorageMp, actualPath)
+	meta, _ := op.GetNearestMeta(reqPath)
+	password := ""
+	if meta != nil {
+		if topMeta, _ := op.GetNearestMeta(stdpath.Join(t.SrcStorageMp, t.SrcActualPath)); topMeta != nil && meta.ID == topMeta.ID {
+			password = meta.Password
+		}
+	}
+	return common.CanAccess(user, meta, reqPath, password)
+}
+
+func (t *ArchiveCompressTask) writeFile(w tool.ArchiveWriter, e compressEntry) error {
+	link, obj, err := op.Link(t.Ctx(), t.SrcStorage, e.actualPath, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: t.Ctx(),
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	defer func() {
+		if err := ss.Close(); err != nil {
+			log.Errorf("failed to close file streamer, %v", err)
+		}
+	}()
+	return w.WriteFile(e.name, obj.GetSize(), obj.ModTime(), ss)
+}
+
+var ArchiveCompressTaskManager *tache.Manager[*ArchiveCompressTask]
+
 func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -356,7 +575,7 @@ func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (
 }
 
 func archiveList(ctx context.Context, path string, args model.ArchiveListArgs) ([]model.Obj, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -364,11 +583,11 @@ func archiveList(ctx context.Context, path string, args model.ArchiveListArgs) (
 }
 
 func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args model.ArchiveDecompressArgs, lazyCache ...bool) (task.TaskExtensionInfo, error) {
-	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	srcStorage, srcObjActualPath, err := getStorageAndActualPath(ctx, srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
 	}
-	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	dstStorage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
@@ -422,7 +641,7 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 }
 
 func archiveDriverExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -430,9 +649,51 @@ func archiveDriverExtract(ctx context.Context, path string, args model.ArchiveIn
 }
 
 func archiveInternalExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (io.ReadCloser, int64, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, 0, errors.WithMessage(err, "failed get storage")
 	}
 	return op.InternalExtract(ctx, storage, actualPath, args)
 }
+
+func archiveCompress(ctx context.Context, srcDirPath, dstDirPath string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	compressor, err := tool.GetCompressor(args.Format)
+	if err != nil {
+		return nil, err
+	}
+	if args.Password != "" && !compressor.CanEncrypt(args.Format) {
+		return nil, errors.WithMessagef(errs.NotSupport, "%s archive does not support password", args.Format)
+	}
+	for _, name := range args.Names {
+		if p := stdpath.Join(srcDirPath, name); utils.PathEqual(p, srcDirPath) || !utils.IsSubPath(srcDirPath, p) {
+			return nil, errors.WithMessagef(errs.PermissionDenied, "invalid name: %s", name)
+		}
+	}
+	srcStorage, srcDirActualPath, err := getStorageAndActualPath(ctx, srcDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	tsk := &ArchiveCompressTask{
+		TaskData: TaskData{
+			SrcStorage:    srcStorage,
+			DstStorage:    dstStorage,
+			SrcActualPath: srcDirActualPath,
+			DstActualPath: dstDirActualPath,
+			SrcStorageMp:  srcStorage.GetStorage().MountPath,
+			DstStorageMp:  dstStorage.GetStorage().MountPath,
+		},
+		ArchiveCompressArgs: args,
+	}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		tsk.Base.SetCtx(ctx)
+		return nil, tsk.compress()
+	}
+	tsk.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	tsk.ApiUrl = common.GetApiUrl(ctx)
+	ArchiveCompressTaskManager.Add(tsk)
+	return tsk, nil
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestArchiveCompressWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"dir/a.txt", "dir/hidden/b.txt", "dir/locked/c.txt", "dir/open/d.txt"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, p), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/compress_walk",
+		Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := op.GetStorageByMountPath("/compress_walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, meta := range []*model.Meta{
+		{Path: "/compress_walk/dir", Hide: "^hidden$"},
+		{Path: "/compress_walk/dir/locked", Password: "secret", PSub: true},
+	} {
+		if err = op.CreateMeta(meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	walk := func(user *model.User) []string {
+		tsk := &ArchiveCompressTask{TaskData: TaskData{SrcStorage: storage, SrcStorageMp: "/compress_walk", SrcActualPath: "/"}}
+		tsk.Base.SetCtx(context.WithValue(context.Background(), conf.UserKey, user))
+		obj, err := op.Get(tsk.Ctx(), storage, "/dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []compressEntry
+		if _, err = tsk.walk("/dir", "dir", obj, &entries); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.name)
+		}
+		slices.Sort(names)
+		return names
+	}
+	got := walk(&model.User{Role: model.GENERAL})
+	want := []string{"dir", "dir/a.txt", "dir/open", "dir/open/d.txt"}
+	if !slices.Equal(got, want) {
+		t.Errorf("the hidden and locked folders should be skipped, got %v", got)
+	}
+	// the admin sees the hidden folders and needs no password
+	if got = walk(&model.User{Role: model.ADMIN, Permission: 0x71FF}); len(got) != 8 {
+		t.Errorf("the admin should compress all the objects, got %v", got)
+	}
+}
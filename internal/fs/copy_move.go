@@ -7,12 +7,15 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/tache"
@@ -80,10 +83,12 @@ func (t *FileTransferTask) Run() error {
 
 func (t *FileTransferTask) OnSucceeded() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	task.HandleStateHook(t, true)
 }
 
 func (t *FileTransferTask) OnFailed() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	task.HandleStateHook(t, false)
 }
 
 func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
@@ -101,11 +106,11 @@ func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
 }
 
 func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
-	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	srcStorage, srcObjActualPath, err := getStorageAndActualPath(ctx, srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
 	}
-	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	dstStorage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
@@ -115,13 +120,21 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 			ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 		}
 		if taskType == copy || taskType == merge {
-			err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
-			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
+			done, err := copyInStorage(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
+			if done {
 				return nil, err
 			}
 		} else {
 			err = op.Move(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
 			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
+				// the moves across the storages are handled once the source is removed
+				if err == nil {
+					dstObjPath := stdpath.Join(dstDirPath, stdpath.Base(srcObjPath))
+					moveWebdavProps(srcObjPath, dstObjPath)
+					if ctx.Value(conf.TrashAccessKey) == nil {
+						webhook.EmitFs(ctx, webhook.EventMove, srcObjPath, dstObjPath)
+					}
+				}
 				return nil, err
 			}
 		}
@@ -155,6 +168,8 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 		}
 		t.Base.SetCtx(ctx)
 		err = t.RunWithNextTaskCallback(callback)
+		// a single file is transferred without the callback
+		hasSuccess = hasSuccess || err == nil
 		if taskType == move {
 			task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathToRemove(srcObjPath))
 		}
@@ -186,6 +201,8 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 		if err != nil {
 			return errors.WithMessagef(err, "failed list src [%s] objs", t.SrcActualPath)
 		}
+		// the trash keeps the removed objects of the other users
+		objs = hideTrash(t.Ctx(), t.SrcActualPath, objs)
 		dstActualPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
 		task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.DstPathToHook(dstActualPath))
 
@@ -255,7 +272,39 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 	}
 	t.SetTotalBytes(ss.GetSize())
 	t.Status = "uploading"
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	ctx := context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{})
+	if t.TaskType == move {
+		// the moved file is removed from the src, the usage is not changed
+		return op.Put(ctx, t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	}
+	return quota.Put(ctx, t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+}
+
+// copyInStorage copies by the storage itself, the file copied is accounted to the user in ctx.
+// It returns false if the storage can't copy, or the src is a dir and the user has a quota,
+// then the copy is done by a transfer task that accounts every file.
+func copyInStorage(ctx context.Context, storage driver.Driver, srcObjActualPath, dstDirActualPath string) (bool, error) {
+	var size int64
+	if _, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		srcObj, err := op.Get(ctx, storage, srcObjActualPath)
+		if err != nil {
+			return true, errors.WithMessage(err, "failed get src object")
+		}
+		if srcObj.IsDir() && quota.Limited(ctx) {
+			return false, nil
+		}
+		if !srcObj.IsDir() {
+			size = srcObj.GetSize()
+		}
+	}
+	if err := quota.Reserve(ctx, size); err != nil {
+		return true, err
+	}
+	err := op.Copy(ctx, storage, srcObjActualPath, dstDirActualPath)
+	if err != nil {
+		quota.Release(ctx, size)
+	}
+	return !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport), err
 }
 
 var (
@@ -3,14 +3,17 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/pkg/errors"
 )
 
@@ -65,6 +68,7 @@ func MakeDir(ctx context.Context, path string) error {
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	audit.Record(ctx, audit.OpMkdir, path, "", err)
 	return err
 }
 
@@ -73,6 +77,7 @@ func Move(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (ta
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
+	audit.Record(ctx, audit.OpMove, srcPath, dstDirPath, err)
 	return req, err
 }
 
@@ -81,6 +86,7 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit.Record(ctx, audit.OpCopy, srcObjPath, dstDirPath, err)
 	return res, err
 }
 
@@ -89,6 +95,7 @@ func Merge(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed merge %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit.Record(ctx, audit.OpMerge, srcObjPath, dstDirPath, err)
 	return res, err
 }
 
@@ -97,6 +104,12 @@ func Rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) erro
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
+	dstPath := stdpath.Join(stdpath.Dir(srcPath), dstName)
+	audit.Record(ctx, audit.OpRename, srcPath, dstPath, err)
+	if err == nil {
+		moveWebdavProps(srcPath, dstPath)
+		webhook.EmitFs(ctx, webhook.EventRename, srcPath, dstPath)
+	}
 	return err
 }
 
@@ -105,6 +118,10 @@ func Remove(ctx context.Context, path string) error {
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
+	audit.Record(ctx, audit.OpRemove, path, "", err)
+	if err == nil {
+		removeWebdavProps(path)
+	}
 	return err
 }
 
@@ -113,6 +130,11 @@ func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	dstPath := stdpath.Join(dstDirPath, file.GetName())
+	audit.Record(ctx, audit.OpPut, "", dstPath, err)
+	if err == nil {
+		webhook.EmitFs(ctx, webhook.EventUpload, dstPath, "")
+	}
 	return err
 }
 
@@ -121,6 +143,7 @@ func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Record(ctx, audit.OpPut, "", stdpath.Join(dstDirPath, file.GetName()), err)
 	return t, err
 }
 
@@ -148,6 +171,14 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	return t, err
 }
 
+func ArchiveCompress(ctx context.Context, srcDirPath, dstDirPath string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	t, err := archiveCompress(ctx, srcDirPath, dstDirPath, args)
+	if err != nil {
+		log.Errorf("failed compress %v in %s to %s: %+v", args.Names, srcDirPath, dstDirPath, err)
+	}
+	return t, err
+}
+
 func ArchiveDriverExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
 	l, obj, err := archiveDriverExtract(ctx, path, args)
 	if err != nil {
@@ -184,7 +215,7 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 }
 
 func PutURL(ctx context.Context, path, dstName, urlStr string) error {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
@@ -8,12 +8,13 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/pkg/errors"
 )
 
 func makeDir(ctx context.Context, path string) error {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
@@ -21,7 +22,7 @@ func makeDir(ctx context.Context, path string) error {
 }
 
 func rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
-	storage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	storage, srcActualPath, err := getStorageAndActualPath(ctx, srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
@@ -32,15 +33,23 @@ func rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) erro
 }
 
 func remove(ctx context.Context, path string) error {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
-	return op.Remove(ctx, storage, actualPath)
+	// objects already in the trash are always deleted permanently
+	if storage.GetStorage().EnableTrash && !op.IsTrashPath(actualPath) {
+		return trash(ctx, storage, path)
+	}
+	if err = op.Remove(ctx, storage, actualPath); err != nil {
+		return err
+	}
+	webhook.EmitFs(ctx, webhook.EventRemove, path, "")
+	return nil
 }
 
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(args.Path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, args.Path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -6,6 +6,7 @@ import (
 	stdpath "path"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
 	"github.com/OpenListTeam/tache"
@@ -39,15 +41,19 @@ func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.storage, t.dstDirActualPath, t.file, t.SetProgress)
+	return quota.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.storage, t.dstDirActualPath, t.file, t.SetProgress)
 }
 
 func (t *UploadTask) OnSucceeded() {
-	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), true)
+	dstDirPath := stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), dstDirPath, true)
+	webhook.EmitFs(t.Ctx(), webhook.EventUpload, stdpath.Join(dstDirPath, t.file.GetName()), "")
+	task.HandleStateHook(t, true)
 }
 
 func (t *UploadTask) OnFailed() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), false)
+	task.HandleStateHook(t, false)
 }
 
 func (t *UploadTask) SetRetry(retry int, maxRetry int) {
@@ -62,13 +68,16 @@ var UploadTaskManager *tache.Manager[*UploadTask]
 
 // putAsTask add as a put task and return immediately
 func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	if err = quota.Check(ctx, file.GetSize()); err != nil {
+		return nil, err
+	}
 	if file.NeedStore() {
 		_, err := file.CacheFullAndWriter(nil, nil)
 		if err != nil {
@@ -95,7 +104,7 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 
 // putDirect put the file and return after finish
 func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) error {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		_ = file.Close()
 		return errors.WithMessage(err, "failed get storage")
@@ -107,13 +116,17 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	if utils.IsBool(skipHook...) {
 		ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 	}
-	return op.Put(ctx, storage, dstDirActualPath, file, nil)
+	return quota.Put(ctx, storage, dstDirActualPath, file, nil)
 }
 
 func getDirectUploadInfo(ctx context.Context, tool, dstDirPath, dstName string, fileSize int64) (any, error) {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
+	// the content does not pass through the server, so it can be checked but not accounted
+	if err = quota.Check(ctx, fileSize); err != nil {
+		return nil, err
+	}
 	return op.GetDirectUploadInfo(ctx, tool, storage, dstDirActualPath, dstName, fileSize)
 }
@@ -0,0 +1,201 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// withTrashAccess marks the context of moving objects into and out of the trash
+func withTrashAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, conf.TrashAccessKey, struct{}{})
+}
+
+// checkTrashAccess only allows the admins and the owners of the removed objects to access the trash of
+// a storage, the removed objects are in /.openlist_trash/{id}. The context without a user is internal.
+func checkTrashAccess(ctx context.Context, actualPath string) error {
+	if !op.IsTrashPath(actualPath) || ctx.Value(conf.TrashAccessKey) != nil {
+		return nil
+	}
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok || user.IsAdmin() {
+		return nil
+	}
+	rest, ok := strings.CutPrefix(utils.FixAndCleanPath(actualPath), "/"+op.TrashDirName+"/")
+	if ok {
+		id, _, _ := strings.Cut(rest, "/")
+		if item, err := db.GetTrashItemById(id); err == nil && item.Username == user.Username {
+			return nil
+		}
+	}
+	return errors.WithStack(errs.PermissionDenied)
+}
+
+// hideTrash removes the trash folder from the objects listed in the root of a storage,
+// unless the objects are moved into or out of the trash
+func hideTrash(ctx context.Context, actualPath string, objs []model.Obj) []model.Obj {
+	if ctx.Value(conf.TrashAccessKey) != nil || !utils.PathEqual(actualPath, "/") {
+		return objs
+	}
+	return op.HideTrashDir(objs)
+}
+
+// getStorageAndActualPath is op.GetStorageAndActualPath checking the access to the trash
+func getStorageAndActualPath(ctx context.Context, path string) (driver.Driver, string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return storage, actualPath, err
+	}
+	if err = checkTrashAccess(ctx, actualPath); err != nil {
+		return nil, "", err
+	}
+	return storage, actualPath, nil
+}
+
+// trash moves the object into the trash of the storage, or the trash storage if configured,
+// the move is done synchronously even if it crosses storages.
+func trash(ctx context.Context, storage driver.Driver, path string) error {
+	obj, err := get(ctx, path, &GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return errors.WithMessage(err, "failed get object")
+	}
+	trashMountPath := storage.GetStorage().TrashStorage
+	if trashMountPath == "" {
+		trashMountPath = storage.GetStorage().MountPath
+	} else if _, err = op.GetStorageByMountPath(trashMountPath); err != nil {
+		// the trash would be visible to everyone if it is not in the root of a storage
+		return errors.WithMessage(err, "failed get trash storage")
+	}
+	item := &model.TrashItem{
+		ID:        random.String(16),
+		Path:      path,
+		Name:      obj.GetName(),
+		Size:      obj.GetSize(),
+		IsDir:     obj.IsDir(),
+		RemovedAt: time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.Username = user.Username
+	}
+	ctx = withTrashAccess(ctx)
+	item.TrashDir = stdpath.Join(utils.FixAndCleanPath(trashMountPath), op.TrashDirName, item.ID)
+	if err = makeDir(ctx, item.TrashDir); err != nil {
+		return errors.WithMessage(err, "failed make trash dir")
+	}
+	_, err = transfer(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), move, path, item.TrashDir, true)
+	if err != nil {
+		if e := purgeTrashDir(ctx, item.TrashDir); e != nil {
+			log.Errorf("failed remove trash dir %s: %+v", item.TrashDir, e)
+		}
+		return errors.WithMessage(err, "failed move to trash")
+	}
+	if err = db.CreateTrashItem(item); err != nil {
+		return err
+	}
+	webhook.EmitFs(ctx, webhook.EventRemove, path, "")
+	return nil
+}
+
+func purgeTrashDir(ctx context.Context, trashDir string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(trashDir)
+	if err != nil {
+		return err
+	}
+	if err = op.Remove(ctx, storage, actualPath); err != nil {
+		return err
+	}
+	removeWebdavProps(trashDir)
+	return nil
+}
+
+// RestoreTrash moves the object in the trash back to its original path
+func RestoreTrash(ctx context.Context, id string) error {
+	item, err := db.GetTrashItemById(id)
+	if err != nil {
+		return err
+	}
+	err = restoreTrash(ctx, item)
+	if err != nil {
+		log.Errorf("failed restore %s: %+v", item.Path, err)
+	}
+	audit.Record(ctx, audit.OpTrashRestore, stdpath.Join(item.TrashDir, item.Name), item.Path, err)
+	return err
+}
+
+func restoreTrash(ctx context.Context, item *model.TrashItem) error {
+	ctx = withTrashAccess(ctx)
+	if _, err := get(ctx, item.Path, &GetArgs{NoLog: true}); err == nil {
+		return errors.Errorf("%s already exists", item.Path)
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed check original path")
+	}
+	dstDirPath := stdpath.Dir(item.Path)
+	if err := makeDir(ctx, dstDirPath); err != nil {
+		return errors.WithMessage(err, "failed make original dir")
+	}
+	_, err := transfer(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), move, stdpath.Join(item.TrashDir, item.Name), dstDirPath)
+	if err != nil {
+		return err
+	}
+	if err = purgeTrashDir(ctx, item.TrashDir); err != nil {
+		log.Errorf("failed remove trash dir %s: %+v", item.TrashDir, err)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrash deletes the object in the trash permanently
+func PurgeTrash(ctx context.Context, id string) error {
+	item, err := db.GetTrashItemById(id)
+	if err != nil {
+		return err
+	}
+	return purgeTrash(ctx, item)
+}
+
+func purgeTrash(ctx context.Context, item *model.TrashItem) error {
+	err := purgeTrashDir(ctx, item.TrashDir)
+	// the storage may have been deleted, nothing is left to purge then
+	if errors.Is(err, errs.StorageNotFound) {
+		err = nil
+	}
+	if err == nil {
+		err = db.DeleteTrashItemById(item.ID)
+	}
+	if err != nil {
+		log.Errorf("failed purge %s: %+v", item.Path, err)
+	}
+	audit.Record(ctx, audit.OpTrashPurge, stdpath.Join(item.TrashDir, item.Name), "", err)
+	return err
+}
+
+// PurgeExpiredTrash deletes the objects removed before the given time permanently
+func PurgeExpiredTrash(ctx context.Context, before time.Time) (int, error) {
+	items, err := db.GetTrashItemsBefore(before)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for i := range items {
+		if purgeTrash(ctx, &items[i]) == nil {
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,198 @@
+package fs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestCheckTrashAccess(t *testing.T) {
+	if err := db.CreateTrashItem(&model.TrashItem{ID: "item1", Username: "bob", Name: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	withUser := func(u *model.User) context.Context {
+		return context.WithValue(context.Background(), conf.UserKey, u)
+	}
+	admin := withUser(&model.User{Username: "admin", Role: model.ADMIN})
+	bob := withUser(&model.User{Username: "bob", Role: model.GENERAL})
+	alice := withUser(&model.User{Username: "alice", Role: model.GENERAL})
+	tests := []struct {
+		ctx     context.Context
+		path    string
+		allowed bool
+	}{
+		{alice, "/docs/a.txt", true},
+		{alice, "/.openlist_trash", false},
+		{alice, "/.openlist_trash/item1/a.txt", false},
+		{alice, "/.openlist_trash/item2", false},
+		{bob, "/.openlist_trash", false},
+		{bob, "/.openlist_trash/item1/a.txt", true},
+		{admin, "/.openlist_trash", true},
+		{context.Background(), "/.openlist_trash/item1", true},
+		{withTrashAccess(alice), "/.openlist_trash/item3", true},
+	}
+	for _, tt := range tests {
+		if err := checkTrashAccess(tt.ctx, tt.path); (err == nil) != tt.allowed {
+			user, _ := tt.ctx.Value(conf.UserKey).(*model.User)
+			t.Errorf("checkTrashAccess(%v, %s) = %v", user, tt.path, err)
+		}
+	}
+}
+
+func TestHideTrash(t *testing.T) {
+	objs := []model.Obj{&model.Object{Name: "docs", IsFolder: true}, &model.Object{Name: op.TrashDirName, IsFolder: true}}
+	if got := hideTrash(context.Background(), "/", objs); len(got) != 1 || got[0].GetName() != "docs" {
+		t.Errorf("the trash in the root should be hidden, got %v", got)
+	}
+	if got := hideTrash(context.Background(), "/docs", objs); len(got) != 2 {
+		t.Errorf("only the trash in the root should be hidden, got %v", got)
+	}
+	if got := hideTrash(withTrashAccess(context.Background()), "/", objs); len(got) != 2 {
+		t.Errorf("the trash should be kept with the trash access, got %v", got)
+	}
+}
+
+func TestTrashWebdavProps(t *testing.T) {
+	for _, mountPath := range []string{"/props_src", "/props_dst"} {
+		root := t.TempDir()
+		if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	hasProp := func(path string) bool {
+		props, err := db.GetWebdavPropsUnder(path, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(props) == 1
+	}
+	if err := db.PatchWebdavProps("/props_src/a.txt", []model.WebdavProp{{Space: "DAV:", Local: "x", InnerXML: "v"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err := op.GetStorageByMountPath("/props_src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = trash(ctx, storage, "/props_src/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	items, err := db.GetTrashItemsBefore(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := slices.IndexFunc(items, func(item model.TrashItem) bool { return item.Path == "/props_src/a.txt" })
+	if idx < 0 {
+		t.Fatal("the trash item is not created")
+	}
+	item := &items[idx]
+	if hasProp("/props_src/a.txt") || !hasProp(stdpath.Join(item.TrashDir, "a.txt")) {
+		t.Error("the props should follow the object into the trash")
+	}
+	if err = restoreTrash(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+	if !hasProp("/props_src/a.txt") || hasProp(stdpath.Join(item.TrashDir, "a.txt")) {
+		t.Error("the props should follow the restored object")
+	}
+
+	// the moves across the storages move the props once the source is removed
+	if _, err = transfer(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), move, "/props_src/a.txt", "/props_dst/sub"); err != nil {
+		t.Fatal(err)
+	}
+	if hasProp("/props_src/a.txt") || !hasProp("/props_dst/sub/a.txt") {
+		t.Error("the props should follow the object moved across the storages")
+	}
+}
+
+func TestTrashWebhookEvents(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/hook_src",
+		Trash:     model.Trash{EnableTrash: true},
+		Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	w := &model.Webhook{URL: server.URL, Events: "fs.remove,fs.move", Paths: "/hook_src/**"}
+	if err = webhook.CreateWebhook(w); err != nil {
+		t.Fatal(err)
+	}
+	defer webhook.DeleteWebhookById(w.ID)
+	events := func() []string {
+		deliveries, _, err := db.GetWebhookDeliveries(w.ID, 1, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, d := range deliveries {
+			res = append(res, d.Event)
+		}
+		return res
+	}
+
+	ctx := context.Background()
+	if err = Remove(ctx, "/hook_src/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := events(); !slices.Equal(got, []string{webhook.EventRemove}) {
+		t.Errorf("trashing should only emit a removal, got %v", got)
+	}
+	items, err := db.GetTrashItemsBefore(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := slices.IndexFunc(items, func(item model.TrashItem) bool { return item.Path == "/hook_src/a.txt" })
+	if idx < 0 {
+		t.Fatal("the trash item is not created")
+	}
+	if err = restoreTrash(ctx, &items[idx]); err != nil {
+		t.Fatal(err)
+	}
+	if got := events(); len(got) != 1 {
+		t.Errorf("restoring should not emit a move, got %v", got)
+	}
+}
@@ -0,0 +1,20 @@
+package fs
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// moveWebdavProps keeps the dead properties set by the WebDAV clients with the object,
+// the moves across the storages are followed by task_group.HookAndRemove once the source is removed
+func moveWebdavProps(srcPath, dstPath string) {
+	if err := db.MoveWebdavProps(srcPath, dstPath); err != nil {
+		log.Errorf("failed move webdav props of %s to %s: %+v", srcPath, dstPath, err)
+	}
+}
+
+func removeWebdavProps(path string) {
+	if err := db.DeleteWebdavProps(path); err != nil {
+		log.Errorf("failed remove webdav props of %s: %+v", path, err)
+	}
+}
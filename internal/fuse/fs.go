@@ -1,170 +1,399 @@
-package fuse
+//go:build fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+package fuse
 
+import (
+	"context"
+	"errors"
+	stdpath "path"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+const blockSize = 4096
+
+// Fs exposes the OpenList virtual file system rooted at RootFolder through FUSE.
+// Every operation is performed with the user carried by ctx.
 type Fs struct {
 	RootFolder string
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
+	ctx     context.Context
+	nextFh  atomic.Uint64
+	handles generic_sync.MapOf[uint64, *fileHandle]
+	// files created or opened for writing that have not been uploaded yet
+	pending generic_sync.MapOf[string, *fileHandle]
+}
 
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+func NewFs(ctx context.Context, rootFolder string) *Fs {
+	return &Fs{RootFolder: utils.FixAndCleanPath(rootFolder), ctx: ctx}
 }
 
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+func (f *Fs) fullPath(path string) string {
+	return stdpath.Join(f.RootFolder, utils.FixAndCleanPath(path))
 }
 
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
+func (f *Fs) addHandle(h *fileHandle) uint64 {
+	fh := f.nextFh.Add(1)
+	f.handles.Store(fh, h)
+	return fh
 }
 
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
+func (f *Fs) Init() {
+	log.Infof("fuse: serving [%s]", f.RootFolder)
 }
 
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
+func (f *Fs) Destroy() {
+	f.handles.Range(func(fh uint64, h *fileHandle) bool {
+		_ = h.close()
+		return true
+	})
+	f.handles.Clear()
+	f.pending.Clear()
 }
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	stat.Bsize = blockSize
+	stat.Frsize = blockSize
+	stat.Namemax = 255
+	// report a large free space unless the storage knows better,
+	// otherwise tools like cp refuse to write anything
+	var total, free uint64 = 1 << 50, 1 << 50
+	if storage, err := f.getStorage(path); err == nil {
+		if details, err := op.GetStorageDetails(f.ctx, storage); err == nil && details.TotalSpace > 0 {
+			total, free = details.TotalSpace, details.FreeSpace
+		}
+	}
+	stat.Blocks = total / blockSize
+	stat.Bfree = free / blockSize
+	stat.Bavail = stat.Bfree
+	return 0
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	return errno(fs.MakeDir(f.ctx, f.fullPath(path)))
+}
+
+func (f *Fs) Unlink(path string) int {
+	return errno(fs.Remove(f.ctx, f.fullPath(path)))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	reqPath := f.fullPath(path)
+	objs, err := fs.List(f.ctx, reqPath, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	src, dst := f.fullPath(oldpath), f.fullPath(newpath)
+	if src == dst {
+		return 0
+	}
+	if _, err := fs.Get(f.ctx, dst, &fs.GetArgs{NoLog: true}); err == nil {
+		// rename(2) replaces an existing target
+		if err := fs.Remove(f.ctx, dst); err != nil {
+			return errno(err)
+		}
+	}
+	srcDir, srcName := stdpath.Split(src)
+	dstDir, dstName := stdpath.Split(dst)
+	if srcDir == dstDir {
+		return errno(fs.Rename(f.ctx, src, dstName))
+	}
+	_, err := fs.Move(context.WithValue(f.ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
+	if err != nil {
+		return errno(err)
+	}
+	if srcName != dstName {
+		return errno(fs.Rename(f.ctx, stdpath.Join(dstDir, srcName), dstName))
+	}
+	return 0
+}
+
+// Chmod, Chown and Utimens are accepted but ignored, storages have no notion of them.
+
+func (f *Fs) Chmod(path string, mode uint32) int {
+	return 0
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return 0
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	return 0
+}
+
+func (f *Fs) Access(path string, mask uint32) int {
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	reqPath := f.fullPath(path)
+	h, err := newWriteHandle(f.ctx, reqPath, nil)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	// an empty file should still appear after close
+	h.dirty = true
+	f.pending.Store(reqPath, h)
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	reqPath := f.fullPath(path)
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+		return 0, f.addHandle(newReadHandle(f.ctx, reqPath))
+	}
+	var src model.Obj
+	if flags&fuse.O_TRUNC == 0 {
+		src = obj
+	}
+	h, err := newWriteHandle(f.ctx, reqPath, src)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if flags&fuse.O_TRUNC != 0 {
+		h.dirty = true
+	}
+	f.pending.Store(reqPath, h)
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	reqPath := f.fullPath(path)
+	if h, ok := f.pending.Load(reqPath); ok {
+		size, err := h.size()
+		if err != nil {
+			return errno(err)
+		}
+		fillStat(stat, &model.Object{Name: stdpath.Base(reqPath), Size: size, Modified: time.Now()})
+		return 0
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	fillStat(stat, obj)
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if h, ok := f.handles.Load(fh); ok && h.writable() {
+		return errno(h.truncate(size))
+	}
+	reqPath := f.fullPath(path)
+	if h, ok := f.pending.Load(reqPath); ok {
+		return errno(h.truncate(size))
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR
+	}
+	if obj.GetSize() == size {
+		return 0
+	}
+	var src model.Obj
+	if size > 0 {
+		src = obj
+	}
+	h, err := newWriteHandle(f.ctx, reqPath, src)
+	if err != nil {
+		return errno(err)
+	}
+	defer h.close()
+	if err := h.truncate(size); err != nil {
+		return errno(err)
+	}
+	return errno(h.flush())
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	n, err := h.readAt(buff, ofst)
+	if err != nil && n == 0 {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	if !h.writable() {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	return errno(h.flush())
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	f.handles.Delete(fh)
+	err := h.flush()
+	if p, ok := f.pending.Load(h.path); ok && p == h {
+		f.pending.Delete(h.path)
+	}
+	return errno(errors.Join(err, h.close()))
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	obj, err := f.get(f.fullPath(path))
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	reqPath := f.fullPath(path)
+	objs, err := fs.List(f.ctx, reqPath, &fs.ListArgs{})
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	names := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		names[obj.GetName()] = struct{}{}
+		stat := &fuse.Stat_t{}
+		fillStat(stat, obj)
+		if !fill(obj.GetName(), stat, 0) {
+			return 0
+		}
+	}
+	// files being written are visible before their upload finishes
+	dir := utils.PathAddSeparatorSuffix(reqPath)
+	f.pending.Range(func(p string, h *fileHandle) bool {
+		dirPath, name := stdpath.Split(p)
+		if dirPath != dir {
+			return true
+		}
+		if _, ok := names[name]; ok {
+			return true
+		}
+		size, _ := h.size()
+		stat := &fuse.Stat_t{}
+		fillStat(stat, &model.Object{Name: name, Size: size, Modified: time.Now()})
+		return fill(name, stat, 0)
+	})
+	return 0
+}
+
+func (f *Fs) Releasedir(path string, fh uint64) int {
+	return 0
+}
+
+func (f *Fs) get(reqPath string) (model.Obj, error) {
+	return fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+}
+
+func (f *Fs) getStorage(path string) (driver.Driver, error) {
+	storage, _, err := op.GetStorageAndActualPath(f.fullPath(path))
+	return storage, err
+}
+
+func fillStat(stat *fuse.Stat_t, obj model.Obj) {
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0o755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0o644
+		stat.Nlink = 1
+		stat.Size = obj.GetSize()
+		stat.Blocks = (stat.Size + 511) / 512
+	}
+	stat.Blksize = blockSize
+	uid, gid, _ := fuse.Getcontext()
+	stat.Uid, stat.Gid = uid, gid
+	mtime := fuse.NewTimespec(obj.ModTime())
+	stat.Mtim = mtime
+	stat.Atim = mtime
+	stat.Ctim = mtime
+	if ctime := obj.CreateTime(); !ctime.IsZero() {
+		stat.Birthtim = fuse.NewTimespec(ctime)
+	} else {
+		stat.Birthtim = mtime
+	}
+}
+
+// errno converts the errors of internal/fs into negative FUSE error codes
+func errno(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errs.IsNotFoundError(err):
+		return -fuse.ENOENT
+	case errors.Is(err, errs.PermissionDenied):
+		return -fuse.EACCES
+	case errors.Is(err, errs.ObjectAlreadyExists):
+		return -fuse.EEXIST
+	case errors.Is(err, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(err, errs.NotFile):
+		return -fuse.EISDIR
+	case errors.Is(err, errs.UploadNotSupported):
+		return -fuse.EROFS
+	case errs.IsNotSupportError(err), errs.IsNotImplementError(err):
+		return -fuse.ENOTSUP
+	case errors.Is(err, context.Canceled):
+		return -fuse.EINTR
+	default:
+		log.Errorf("fuse: %+v", err)
+		return -fuse.EIO
+	}
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
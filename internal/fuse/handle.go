@@ -0,0 +1,189 @@
+package fuse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// fileHandle is an opened file.
+// Read only handles fetch the link lazily and serve reads with range requests,
+// writable handles stage the whole content in a temp file and upload it on flush.
+type fileHandle struct {
+	ctx  context.Context
+	path string
+	mu   sync.Mutex
+
+	reader model.File
+	closer io.Closer
+
+	tmp   *os.File
+	dirty bool
+}
+
+func newReadHandle(ctx context.Context, path string) *fileHandle {
+	return &fileHandle{ctx: ctx, path: path}
+}
+
+// newWriteHandle creates a writable handle, the content of src is downloaded first if it is not nil
+func newWriteHandle(ctx context.Context, path string, src model.Obj) (*fileHandle, error) {
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return nil, err
+	}
+	h := &fileHandle{ctx: ctx, path: path, tmp: tmp}
+	if src != nil && src.GetSize() > 0 {
+		if err = h.openReader(); err == nil {
+			_, err = utils.CopyWithBuffer(tmp, io.NewSectionReader(h.reader, 0, src.GetSize()))
+		}
+		if err != nil {
+			_ = h.close()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func (h *fileHandle) writable() bool {
+	return h.tmp != nil
+}
+
+func (h *fileHandle) openReader() error {
+	link, obj, err := fs.Link(h.ctx, h.path, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: h.ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	reader, err := stream.NewReadAtSeeker(ss, 0)
+	if err != nil {
+		_ = ss.Close()
+		return err
+	}
+	h.reader, h.closer = reader, ss
+	return nil
+}
+
+func (h *fileHandle) size() (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tmp == nil {
+		return 0, os.ErrInvalid
+	}
+	info, err := h.tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// readAt is serialized with the writes and close, the reader of the link is not safe for concurrent use
+func (h *fileHandle) readAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	staged := h.tmp != nil
+	n, err := h.readAtLocked(p, off)
+	h.mu.Unlock()
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	// only the reads from the storage are limited, not the ones from the staged content
+	if n > 0 && err == nil && !staged {
+		err = stream.ClientDownloadLimit.WaitN(h.ctx, n)
+	}
+	return n, err
+}
+
+func (h *fileHandle) readAtLocked(p []byte, off int64) (int, error) {
+	if h.tmp != nil {
+		return h.tmp.ReadAt(p, off)
+	}
+	if h.reader == nil {
+		if err := h.openReader(); err != nil {
+			return 0, err
+		}
+	}
+	return h.reader.ReadAt(p, off)
+}
+
+func (h *fileHandle) writeAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := h.tmp.WriteAt(p, off)
+	if n > 0 {
+		h.dirty = true
+	}
+	return n, err
+}
+
+func (h *fileHandle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.tmp.Truncate(size); err != nil {
+		return err
+	}
+	h.dirty = true
+	return nil
+}
+
+// flush uploads the staged content if it has been modified since the last flush
+func (h *fileHandle) flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tmp == nil || !h.dirty {
+		return nil
+	}
+	info, err := h.tmp.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = h.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(h.path)
+	s := &stream.FileStream{
+		Ctx: h.ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype: utils.GetMimeType(name),
+		Reader:   h.tmp,
+	}
+	if err = fs.PutDirectly(h.ctx, dir, s); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+func (h *fileHandle) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var err error
+	if h.closer != nil {
+		err = h.closer.Close()
+		h.reader, h.closer = nil, nil
+	}
+	if h.tmp != nil {
+		err = errors.Join(err, h.tmp.Close(), os.Remove(h.tmp.Name()))
+		h.tmp = nil
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package fuse
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"golang.org/x/time/rate"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+	stream.ClientDownloadLimit = stream.NewLimiter(rate.Inf, 0, "client_download")
+}
+
+func setupHandleStorage(t *testing.T, mountPath string) string {
+	root := t.TempDir()
+	conf.Conf.TempDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: mountPath,
+		Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		storage, err := op.GetStorageByMountPath(mountPath)
+		if err == nil {
+			_ = op.DeleteStorageById(context.Background(), storage.GetStorage().ID)
+		}
+	})
+	return root
+}
+
+func readFile(t *testing.T, name string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReadHandle(t *testing.T) {
+	setupHandleStorage(t, "/fuse_TestReadHandle")
+	h := newReadHandle(context.Background(), "/fuse_TestReadHandle/a.txt")
+	defer h.close()
+	if h.writable() {
+		t.Error("the read handle should not be writable")
+	}
+	buf := make([]byte, 5)
+	n, err := h.readAt(buf, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "world" {
+		t.Errorf("read %q at offset 6, want %q", got, "world")
+	}
+	// reading past the end is not an error
+	n, err = h.readAt(buf, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "ld" {
+		t.Errorf("read %q at offset 9, want %q", got, "ld")
+	}
+	// flushing a read handle does nothing
+	if err = h.flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteHandle(t *testing.T) {
+	root := setupHandleStorage(t, "/fuse_TestWriteHandle")
+	ctx := context.Background()
+	src, err := fs.Get(ctx, "/fuse_TestWriteHandle/a.txt", &fs.GetArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := newWriteHandle(ctx, "/fuse_TestWriteHandle/a.txt", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.close()
+	tmp := h.tmp.Name()
+
+	// the content is staged before any write
+	buf := make([]byte, 11)
+	n, err := h.readAt(buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "hello world" {
+		t.Errorf("staged content is %q", got)
+	}
+	if h.dirty {
+		t.Error("the handle should not be dirty before any write")
+	}
+
+	if _, err = h.writeAt([]byte("there"), 6); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(root, "a.txt")); got != "hello world" {
+		t.Errorf("the file should not change before flush, got %q", got)
+	}
+	if err = h.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(root, "a.txt")); got != "hello there" {
+		t.Errorf("flushed content is %q", got)
+	}
+	if h.dirty {
+		t.Error("the handle should not be dirty after flush")
+	}
+
+	if err = h.truncate(5); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := h.size(); err != nil || size != 5 {
+		t.Errorf("size after truncate is %d, %v", size, err)
+	}
+	if err = h.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(root, "a.txt")); got != "hello" {
+		t.Errorf("flushed content after truncate is %q", got)
+	}
+
+	if err = h.close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("the temp file should be removed on close, got %v", err)
+	}
+}
+
+func TestWriteHandleCreate(t *testing.T) {
+	root := setupHandleStorage(t, "/fuse_TestWriteHandleCreate")
+	h, err := newWriteHandle(context.Background(), "/fuse_TestWriteHandleCreate/b.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.close()
+	if _, err = h.writeAt([]byte("new"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(root, "b.txt")); got != "new" {
+		t.Errorf("created content is %q", got)
+	}
+}
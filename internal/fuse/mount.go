@@ -1,9 +1,21 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// NewHost returns a FUSE host serving mountSrc with the user carried by ctx
+func NewHost(ctx context.Context, mountSrc string) *fuse.FileSystemHost {
+	return fuse.NewFileSystemHost(NewFs(ctx, mountSrc))
+}
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
-	host := fuse.NewFileSystemHost(fs)
+// Mount serves mountSrc at mountDst in the background
+func Mount(ctx context.Context, mountSrc, mountDst string, opts []string) *fuse.FileSystemHost {
+	host := NewHost(ctx, mountSrc)
 	go host.Mount(mountDst, opts)
+	return host
 }
@@ -0,0 +1,142 @@
+// Package index_job rebuilds the search index of mount paths on a cron schedule or manually,
+// either fully or incrementally, with the progress kept per job.
+package index_job
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Validate checks the cron expression and the path of the job
+func Validate(j *model.IndexJob) error {
+	j.Cron = strings.TrimSpace(j.Cron)
+	if j.Cron != "" {
+		if _, err := cron.ParseSchedule(j.Cron); err != nil {
+			return err
+		}
+	}
+	if j.MaxDepth < -1 {
+		return errors.New("max depth must be -1 or greater")
+	}
+	j.Path = utils.FixAndCleanPath(j.Path)
+	return nil
+}
+
+func CreateIndexJob(j *model.IndexJob) error {
+	j.ID = 0
+	j.LastRunAt, j.Progress = time.Time{}, model.IndexProgress{IsDone: true}
+	return db.CreateIndexJob(j)
+}
+
+func UpdateIndexJob(j *model.IndexJob) error {
+	old, err := db.GetIndexJobById(j.ID)
+	if err != nil {
+		return err
+	}
+	j.LastRunAt, j.Progress = old.LastRunAt, old.Progress
+	return db.UpdateIndexJob(j)
+}
+
+var (
+	mu sync.Mutex
+	// running is the id of the running job, 0 if none
+	running uint
+)
+
+// Start runs the job in background, it fails if the job or any other index is running.
+// Only one job runs at a time since the search package builds one index at a time.
+func Start(j *model.IndexJob) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if running == j.ID {
+		return errors.Errorf("index job of %s is already running", j.Path)
+	}
+	if running != 0 || search.Running() {
+		return errors.New("index is running")
+	}
+	running = j.ID
+	go func() {
+		defer func() {
+			mu.Lock()
+			running = 0
+			mu.Unlock()
+		}()
+		run(context.Background(), j)
+	}()
+	return nil
+}
+
+func run(ctx context.Context, j *model.IndexJob) {
+	j.LastRunAt = time.Now()
+	done := false
+	report := func(progress *model.IndexProgress) {
+		j.Progress, done = *progress, progress.IsDone
+		if err := db.UpdateIndexJobProgress(j); err != nil {
+			log.Errorf("failed save progress of index job of %s: %+v", j.Path, err)
+		}
+	}
+	maxDepth := j.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = setting.GetInt(conf.MaxIndexDepth, 20)
+	}
+	ignorePaths := conf.SlicesMap[conf.IgnorePaths]
+	var err error
+	if j.Incremental {
+		err = search.IncrementalIndex(ctx, j.Path, ignorePaths, maxDepth, report)
+	} else {
+		err = search.RebuildIndex(ctx, j.Path, ignorePaths, maxDepth, report)
+	}
+	// the errors before the walk starts are not reported by the search package
+	if err != nil && !done {
+		now := time.Now()
+		report(&model.IndexProgress{ObjCount: j.Progress.ObjCount, IsDone: true, LastDoneTime: &now, Error: err.Error()})
+	}
+}
+
+var lastTick time.Time
+
+// tick starts the jobs scheduled between the last tick and now
+func tick() {
+	now := time.Now()
+	defer func() { lastTick = now }()
+	jobs, err := db.GetScheduledIndexJobs()
+	if err != nil {
+		log.Errorf("failed get scheduled index jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		j := &jobs[i]
+		s, err := cron.ParseSchedule(j.Cron)
+		if err != nil {
+			log.Warnf("invalid cron of index job of %s: %v", j.Path, err)
+			continue
+		}
+		if next := s.Next(lastTick); next.IsZero() || next.After(now) {
+			continue
+		}
+		if err = Start(j); err != nil {
+			log.Warnf("skip scheduled index job of %s: %v", j.Path, err)
+		}
+	}
+}
+
+// Init marks the jobs interrupted by the last shutdown as done and checks the scheduled jobs every minute
+func Init() {
+	if err := db.FinishInterruptedIndexJobs(); err != nil {
+		log.Errorf("failed finish interrupted index jobs: %+v", err)
+	}
+	lastTick = time.Now()
+	cron.NewCron(time.Minute).Do(tick)
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "openlist"
+
+// Registry holds all the collectors exposed on /metrics,
+// a dedicated registry is used so that dependencies can't pollute the output
+var Registry = prometheus.NewRegistry()
+
+var (
+	storageRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_requests_total",
+		Help:      "Count of the requests sent to storage drivers.",
+	}, []string{"storage", "op", "result"})
+	storageRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_request_duration_seconds",
+		Help:      "Latency of the requests sent to storage drivers.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"storage", "op"})
+	storageUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "storage_up",
+		Help:      "Whether the last health check of the storage succeeded.",
+	}, []string{"storage"})
+	dirCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dir_cache_lookups_total",
+		Help:      "Count of the directory cache lookups by result.",
+	}, []string{"result"})
+	transferredBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transferred_bytes_total",
+		Help:      "Bytes sent to clients by the way they are served.",
+	}, []string{"via"})
+	rateLimitWait = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_wait_seconds_total",
+		Help:      "Time spent waiting on the stream rate limiters.",
+	}, []string{"limiter"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		storageRequests,
+		storageRequestDuration,
+		storageUp,
+		dirCacheLookups,
+		transferredBytes,
+		rateLimitWait,
+		taskCollector,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveStorageRequest records a driver call of the storage mounted at mountPath
+func ObserveStorageRequest(mountPath, op string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	storageRequests.WithLabelValues(mountPath, op, result).Inc()
+	storageRequestDuration.WithLabelValues(mountPath, op).Observe(time.Since(start).Seconds())
+}
+
+// DeleteStorage drops the series of a removed storage
+func DeleteStorage(mountPath string) {
+	storageRequests.DeletePartialMatch(prometheus.Labels{"storage": mountPath})
+	storageRequestDuration.DeletePartialMatch(prometheus.Labels{"storage": mountPath})
+	storageUp.DeletePartialMatch(prometheus.Labels{"storage": mountPath})
+}
+
+// SetStorageUp records the result of the last health check of the storage
+func SetStorageUp(mountPath string, up bool) {
+	v := 0.0
+	if up {
+		v = 1
+	}
+	storageUp.WithLabelValues(mountPath).Set(v)
+}
+
+func ObserveDirCache(hit bool) {
+	if hit {
+		dirCacheLookups.WithLabelValues("hit").Inc()
+	} else {
+		dirCacheLookups.WithLabelValues("miss").Inc()
+	}
+}
+
+// AddTransferredBytes records n bytes sent to a client, via is either "serve" or "proxy"
+func AddTransferredBytes(via string, n int64) {
+	if n > 0 {
+		transferredBytes.WithLabelValues(via).Add(float64(n))
+	}
+}
+
+func ObserveRateLimitWait(limiter string, d time.Duration) {
+	rateLimitWait.WithLabelValues(limiter).Add(d.Seconds())
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the value of the series in the output of the handler, or -1 if it is missing
+func scrape(t *testing.T, series string) float64 {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("scrape status = %d", w.Code)
+	}
+	s := bufio.NewScanner(w.Body)
+	for s.Scan() {
+		if value, ok := strings.CutPrefix(s.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+	}
+	return -1
+}
+
+func TestObserveStorageRequest(t *testing.T) {
+	success := `openlist_storage_requests_total{op="list",result="success",storage="/metrics"}`
+	failure := `openlist_storage_requests_total{op="list",result="error",storage="/metrics"}`
+	ObserveStorageRequest("/metrics", "list", time.Now(), nil)
+	ObserveStorageRequest("/metrics", "list", time.Now(), nil)
+	ObserveStorageRequest("/metrics", "list", time.Now(), errors.New("failed"))
+	if got := scrape(t, success); got != 2 {
+		t.Errorf("%s = %v, want 2", success, got)
+	}
+	if got := scrape(t, failure); got != 1 {
+		t.Errorf("%s = %v, want 1", failure, got)
+	}
+	if got := scrape(t, `openlist_storage_request_duration_seconds_count{op="list",storage="/metrics"}`); got != 3 {
+		t.Errorf("request duration count = %v, want 3", got)
+	}
+	// the series of a removed storage are dropped
+	DeleteStorage("/metrics")
+	if got := scrape(t, success); got != -1 {
+		t.Errorf("%s = %v after the storage is deleted", success, got)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	hit := `openlist_dir_cache_lookups_total{result="hit"}`
+	served := `openlist_transferred_bytes_total{via="serve"}`
+	before, beforeServed := max(scrape(t, hit), 0), max(scrape(t, served), 0)
+	ObserveDirCache(true)
+	AddTransferredBytes("serve", 100)
+	// nothing is sent
+	AddTransferredBytes("serve", 0)
+	if got := scrape(t, hit); got != before+1 {
+		t.Errorf("%s = %v, want %v", hit, got, before+1)
+	}
+	if got := scrape(t, served); got != beforeServed+100 {
+		t.Errorf("%s = %v, want %v", served, got, beforeServed+100)
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/tache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var stateNames = map[tache.State]string{
+	tache.StatePending:      "pending",
+	tache.StateRunning:      "running",
+	tache.StateSucceeded:    "succeeded",
+	tache.StateCanceling:    "canceling",
+	tache.StateCanceled:     "canceled",
+	tache.StateErrored:      "errored",
+	tache.StateFailing:      "failing",
+	tache.StateFailed:       "failed",
+	tache.StateWaitingRetry: "waiting_retry",
+	tache.StateBeforeRetry:  "before_retry",
+}
+
+type taskManagers struct {
+	mu         sync.RWMutex
+	managers   map[string]func() []tache.State
+	tasks      *prometheus.Desc
+	queueDepth *prometheus.Desc
+}
+
+var taskCollector = &taskManagers{
+	managers: make(map[string]func() []tache.State),
+	tasks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "tasks"),
+		"Count of the tasks held by each task manager by state.", []string{"manager", "state"}, nil),
+	queueDepth: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "task_queue_depth"),
+		"Count of the tasks waiting to be run by each task manager.", []string{"manager"}, nil),
+}
+
+// RegisterTaskManager exposes the task states of m under the given name,
+// the states are collected on every scrape
+func RegisterTaskManager[T tache.Task](name string, m interface{ GetAll() []T }) {
+	taskCollector.mu.Lock()
+	defer taskCollector.mu.Unlock()
+	taskCollector.managers[name] = func() []tache.State {
+		tasks := m.GetAll()
+		states := make([]tache.State, 0, len(tasks))
+		for _, t := range tasks {
+			states = append(states, t.GetState())
+		}
+		return states
+	}
+}
+
+func (c *taskManagers) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tasks
+	ch <- c.queueDepth
+}
+
+func (c *taskManagers) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, states := range c.managers {
+		counts := make(map[tache.State]int, len(stateNames))
+		for _, s := range states() {
+			counts[s]++
+		}
+		for state, stateName := range stateNames {
+			ch <- prometheus.MustNewConstMetric(c.tasks, prometheus.GaugeValue, float64(counts[state]), name, stateName)
+		}
+		queued := counts[tache.StatePending] + counts[tache.StateWaitingRetry]
+		ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(queued), name)
+	}
+}
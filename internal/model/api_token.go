@@ -0,0 +1,43 @@
+package model
+
+import (
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// APIToken is a personal token of a user, only the sha256 hash of the token is stored
+type APIToken struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"-" gorm:"index"`
+	Name   string `json:"name"`
+	// Prefix is the beginning of the token to tell the tokens apart, it is also the S3 access key id
+	Prefix    string `json:"prefix" gorm:"unique"`
+	TokenHash string `json:"-" gorm:"unique"`
+	// BasePath is joined to the base path of the user to restrict the token to a sub path
+	BasePath string `json:"base_path"`
+	// Permission is the subset of the user's permission granted to the token, in the same bits as User.Permission
+	Permission int32      `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *APIToken) Expired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// Apply returns a copy of the user restricted to the base path and permission of the token.
+// An admin is downgraded to a general user, so the token never bypasses its scope by the admin role,
+// but it is marked as AdminToken to keep the limits of the admin
+func (t *APIToken) Apply(u *User) *User {
+	user := *u
+	user.BasePath = utils.FixAndCleanPath(stdpath.Join(u.BasePath, t.BasePath))
+	user.Permission = u.Permission & t.Permission
+	if user.Role == ADMIN {
+		user.Role = GENERAL
+		user.AdminToken = true
+	}
+	return &user
+}
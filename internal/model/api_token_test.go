@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestAPITokenApply(t *testing.T) {
+	admin := &User{ID: 1, Username: "admin", Role: ADMIN, BasePath: "/", Permission: 0x71FF}
+	token := &APIToken{UserID: 1, BasePath: "/docs", Permission: 1<<3 | 1<<16}
+	u := token.Apply(admin)
+	if u.IsAdmin() || u.Role != GENERAL {
+		t.Errorf("the token keeps the admin role: %d", u.Role)
+	}
+	if !u.AdminToken {
+		t.Error("the token of an admin should be marked")
+	}
+	if u.BasePath != "/docs" || u.Permission != 1<<3 {
+		t.Errorf("user = %+v", u)
+	}
+	if !admin.IsAdmin() || admin.BasePath != "/" {
+		t.Errorf("the owner is changed: %+v", admin)
+	}
+	guest := &User{Role: GUEST, BasePath: "/pub"}
+	if u = token.Apply(guest); u.Role != GUEST || u.BasePath != "/pub/docs" || u.AdminToken {
+		t.Errorf("user = %+v", u)
+	}
+}
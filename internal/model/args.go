@@ -81,6 +81,14 @@ type ArchiveDecompressArgs struct {
 	Overwrite     bool
 }
 
+type ArchiveCompressArgs struct {
+	Names       []string
+	ArchiveName string
+	Format      string
+	Password    string
+	Overwrite   bool
+}
+
 type SharingListArgs struct {
 	Refresh bool
 	Pwd     string
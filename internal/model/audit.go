@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	Username  string    `json:"username" gorm:"index"`
+	IP        string    `json:"ip"`
+	Protocol  string    `json:"protocol"`
+	Operation string    `json:"operation" gorm:"index"`
+	SrcPath   string    `json:"src_path" gorm:"type:text"`
+	DstPath   string    `json:"dst_path" gorm:"type:text"`
+	Success   bool      `json:"success"`
+	Message   string    `json:"message" gorm:"type:text"`
+}
+
+type AuditLogFilter struct {
+	Username  string `json:"username" form:"username"`
+	Operation string `json:"operation" form:"operation"`
+	Protocol  string `json:"protocol" form:"protocol"`
+	// Path matches the logs whose src or dst path starts with it
+	Path    string    `json:"path" form:"path"`
+	Success *bool     `json:"success" form:"success"`
+	Start   time.Time `json:"start" form:"start" time_format:"unix"`
+	End     time.Time `json:"end" form:"end" time_format:"unix"`
+}
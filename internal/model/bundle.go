@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// BundleVersion is the version of the bundles exported, the bundles of newer versions can't be imported
+const BundleVersion = 1
+
+// Bundle is the portable configuration of an instance. The users, sharings and ssh keys refer to
+// each other by the usernames, so they can be merged into an instance with other ids.
+type Bundle struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Storages   []Storage         `json:"storages"`
+	Metas      []Meta            `json:"metas"`
+	Users      []BundleUser      `json:"users"`
+	Settings   map[string]string `json:"settings"`
+	Sharings   []BundleSharing   `json:"sharings"`
+	SSHKeys    []BundleSSHKey    `json:"ssh_keys"`
+}
+
+// BundleUser carries the password hash instead of the password
+type BundleUser struct {
+	User
+	PwdHash   string `json:"pwd_hash"`
+	PwdTS     int64  `json:"pwd_ts"`
+	Salt      string `json:"salt"`
+	OtpSecret string `json:"otp_secret"`
+	Authn     string `json:"authn"`
+}
+
+// BundleSharing carries the share code hash, a plain share code of the old versions is hashed on import
+type BundleSharing struct {
+	SharingDB
+	FilesRaw string `json:"files"`
+	Creator  string `json:"creator"`
+	PwdHash  string `json:"pwd_hash"`
+	Salt     string `json:"salt"`
+}
+
+type BundleSSHKey struct {
+	SSHPublicKey
+	KeyStr   string `json:"key"`
+	Username string `json:"username"`
+}
+
+type BundleImportResult struct {
+	Storages int `json:"storages"`
+	Metas    int `json:"metas"`
+	Users    int `json:"users"`
+	Settings int `json:"settings"`
+	Sharings int `json:"sharings"`
+	SSHKeys  int `json:"ssh_keys"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type IndexJob struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Path string `json:"path" gorm:"type:text" binding:"required"`
+	// Cron is a 5 fields cron expression, empty means the job only runs manually
+	Cron string `json:"cron"`
+	// Incremental only indexes the objects changed since the last run instead of rebuilding the path
+	Incremental bool `json:"incremental"`
+	// MaxDepth of the walk, 0 uses the max index depth setting and -1 means unlimited
+	MaxDepth  int           `json:"max_depth"`
+	Disabled  bool          `json:"disabled"`
+	LastRunAt time.Time     `json:"last_run_at"`
+	Progress  IndexProgress `json:"progress" gorm:"embedded;embeddedPrefix:progress_"`
+}
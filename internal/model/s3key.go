@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// S3Key is an access key of a user for the S3 server. The secret has to be kept in plain,
+// since the S3 signatures are verified with it, it is only returned once on creation though.
+type S3Key struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	UserID          uint      `json:"-" gorm:"index"`
+	Name            string    `json:"name"`
+	AccessKeyID     string    `json:"access_key_id" gorm:"unique"`
+	SecretAccessKey string    `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -2,7 +2,10 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
 type IndexProgress struct {
@@ -17,14 +20,61 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	SearchFilter
 	PageReq
 }
 
+// SearchFilter narrows the search by the metadata of the nodes, the zero values mean no limit
+type SearchFilter struct {
+	MinSize int64 `json:"min_size"`
+	MaxSize int64 `json:"max_size"`
+	// unix timestamps in seconds
+	ModifiedAfter  int64 `json:"modified_after"`
+	ModifiedBefore int64 `json:"modified_before"`
+	// Extensions without the leading dot, case-insensitive
+	Extensions []string `json:"extensions"`
+	// Types of conf.FOLDER, conf.VIDEO, conf.AUDIO, conf.TEXT and conf.IMAGE
+	Types []int `json:"types"`
+}
+
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent   string    `json:"parent" gorm:"index"`
+	Name     string    `json:"name"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// Hash is the json of utils.HashInfo, empty if the storage provides none
+	Hash string `json:"hash"`
+	// Ext is the lower case extension without the dot
+	Ext string `json:"ext"`
+	// ObjType is the type of utils.GetObjType
+	ObjType int `json:"type"`
+	// Content is the text extracted from the file, it is only indexed but never returned by the searchers
+	Content string `json:"content,omitempty" gorm:"-"`
+	// Snippet is the highlighted part of the content matching the keywords
+	Snippet string `json:"snippet,omitempty" gorm:"-"`
+}
+
+// NewSearchNode keeps the modified time in UTC, since the times are compared as strings by some databases
+func NewSearchNode(parent string, obj Obj) SearchNode {
+	node := SearchNode{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime().UTC(),
+		ObjType:  utils.GetObjType(obj.GetName(), obj.IsDir()),
+	}
+	if !node.IsDir {
+		node.Ext = utils.Ext(node.Name)
+	}
+	for _, h := range obj.GetHash().All() {
+		if h != "" {
+			node.Hash = obj.GetHash().String()
+			break
+		}
+	}
+	return node
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,6 +84,26 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	return p.SearchFilter.Validate()
+}
+
+func (f *SearchFilter) Validate() error {
+	if f.MinSize < 0 || f.MaxSize < 0 {
+		return fmt.Errorf("size can't < 0")
+	}
+	if f.MaxSize != 0 && f.MinSize > f.MaxSize {
+		return fmt.Errorf("min_size can't > max_size")
+	}
+	if f.ModifiedBefore != 0 && f.ModifiedAfter > f.ModifiedBefore {
+		return fmt.Errorf("modified_after can't > modified_before")
+	}
+	exts := make([]string, 0, len(f.Extensions))
+	for _, ext := range f.Extensions {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+	f.Extensions = exts
 	return nil
 }
 
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+const (
+	ShareAccessGet      = "get"
+	ShareAccessList     = "list"
+	ShareAccessArchive  = "archive"
+	ShareAccessDownload = "download"
+	ShareAccessUpload   = "upload"
+)
+
+// ShareAccess is a visit of a sharing, the path is relative to the sharing
+type ShareAccess struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SharingID string    `json:"sharing_id" gorm:"type:char(12);index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent" gorm:"type:text"`
+	Action    string    `json:"action"`
+	Path      string    `json:"path" gorm:"type:text"`
+	// Bytes is the bytes sent by the server, it is 0 if the download is redirected,
+	// or the bytes received for an upload
+	Bytes int64 `json:"bytes"`
+}
+
+type ShareFileStat struct {
+	Path      string `json:"path"`
+	Downloads int64  `json:"downloads"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type ShareDailyStat struct {
+	Date           string `json:"date"`
+	Visits         int    `json:"visits"`
+	UniqueVisitors int    `json:"unique_visitors"`
+}
+
+type ShareStats struct {
+	// Accessed is the access count of the sharing, which counts an ip once in a while
+	Accessed       int              `json:"accessed"`
+	Visits         int              `json:"visits"`
+	UniqueVisitors int              `json:"unique_visitors"`
+	Bytes          int64            `json:"bytes"`
+	Files          []ShareFileStat  `json:"files"`
+	Daily          []ShareDailyStat `json:"daily"`
+}
@@ -1,12 +1,22 @@
 package model
 
-import "time"
+import (
+	"crypto/subtle"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+)
 
 type SharingDB struct {
 	ID          string     `json:"id" gorm:"type:char(12);primaryKey"`
 	FilesRaw    string     `json:"-" gorm:"type:text"`
 	Expires     *time.Time `json:"expires"`
-	Pwd         string     `json:"pwd"`
+	Pwd         string     `json:"pwd"` // plain share code of old versions, only kept until it is hashed
+	PwdHash     string     `json:"-"`   // share code hash
+	Salt        string     `json:"-"`   // unique salt
 	Accessed    int        `json:"accessed"`
 	MaxAccessed int        `json:"max_accessed"`
 	CreatorId   uint       `json:"-"`
@@ -15,6 +25,21 @@ type SharingDB struct {
 	Readme      string     `json:"readme" gorm:"type:text"`
 	Header      string     `json:"header" gorm:"type:text"`
 	Sort
+	// Upload allows the visitors to upload into the folders of the sharing as the creator
+	Upload bool `json:"upload"`
+	// UploadMaxSize is the max bytes of an uploaded file, 0 means unlimited
+	UploadMaxSize int64 `json:"upload_max_size"`
+	// UploadExtensions is the comma separated extensions allowed to upload, empty means all
+	UploadExtensions string `json:"upload_extensions"`
+	// HideFiles hides the content of the sharing, so the visitors can upload without seeing the files of the others
+	HideFiles bool `json:"hide_files"`
+	// NotBefore is the time from which the sharing can be accessed
+	NotBefore *time.Time `json:"not_before"`
+	// SpeedLimit is the max download speed of the sharing in KB/s shared by all visitors, 0 means unlimited
+	SpeedLimit int `json:"speed_limit"`
+	// MaxBytes is the max bytes downloaded from the sharing, the sharing is disabled once it is reached, 0 means unlimited
+	MaxBytes    int64 `json:"max_bytes"`
+	BytesServed int64 `json:"bytes_served"`
 }
 
 type Sharing struct {
@@ -30,6 +55,9 @@ func (s *Sharing) Valid() bool {
 	if s.MaxAccessed > 0 && s.Accessed >= s.MaxAccessed {
 		return false
 	}
+	if s.BytesExceeded() {
+		return false
+	}
 	if len(s.Files) == 0 {
 		return false
 	}
@@ -39,9 +67,63 @@ func (s *Sharing) Valid() bool {
 	if s.Expires != nil && !s.Expires.IsZero() && s.Expires.Before(time.Now()) {
 		return false
 	}
+	if s.NotBefore != nil && !s.NotBefore.IsZero() && s.NotBefore.After(time.Now()) {
+		return false
+	}
 	return true
 }
 
+// HasDownloadLimit reports whether the download speed or bytes of the sharing is limited
+func (s *Sharing) HasDownloadLimit() bool {
+	return s.SpeedLimit > 0 || s.MaxBytes > 0
+}
+
+func (s *Sharing) BytesExceeded() bool {
+	return s.MaxBytes > 0 && atomic.LoadInt64(&s.BytesServed) >= s.MaxBytes
+}
+
+func (s *Sharing) HasPwd() bool {
+	return s.PwdHash != "" || s.Pwd != ""
+}
+
 func (s *Sharing) Verify(pwd string) bool {
-	return s.Pwd == "" || s.Pwd == pwd
+	// the plain share codes are hashed at startup, the ones left are never accepted
+	if s.PwdHash == "" {
+		return s.Pwd == ""
+	}
+	return subtle.ConstantTimeCompare([]byte(s.PwdHash), []byte(TwoHashPwd(pwd, s.Salt))) == 1
+}
+
+// SetPwd hashes the share code with a new salt, an empty code removes the share code
+func (s *SharingDB) SetPwd(pwd string) *SharingDB {
+	s.Pwd = ""
+	if pwd == "" {
+		s.PwdHash, s.Salt = "", ""
+		return s
+	}
+	s.Salt = random.String(16)
+	s.PwdHash = TwoHashPwd(pwd, s.Salt)
+	return s
+}
+
+// Hidden reports whether the path in the sharing is hidden from the visitors, the root is always visible
+func (s *Sharing) Hidden(path string) bool {
+	return s.HideFiles && path != "/"
+}
+
+// AllowExtension reports whether a file of the name is allowed to upload by its extension
+func (s *Sharing) AllowExtension(name string) bool {
+	if s.UploadExtensions == "" {
+		return true
+	}
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	if ext == "" {
+		return false
+	}
+	for _, e := range strings.Split(s.UploadExtensions, ",") {
+		if strings.TrimPrefix(strings.ToLower(strings.TrimSpace(e)), ".") == ext {
+			return true
+		}
+	}
+	return false
 }
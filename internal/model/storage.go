@@ -20,6 +20,7 @@ type Storage struct {
 	EnableSign          bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	Trash
 }
 
 type Sort struct {
@@ -37,6 +38,13 @@ type Proxy struct {
 	DisableProxySign bool `json:"disable_proxy_sign"`
 }
 
+type Trash struct {
+	// EnableTrash makes removing move the objects into the trash instead of deleting them
+	EnableTrash bool `json:"enable_trash"`
+	// TrashStorage is the mount path of the storage keeping the trash, empty means the storage itself
+	TrashStorage string `json:"trash_storage"`
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
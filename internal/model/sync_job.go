@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	// SyncMirror makes the destination the same as the source, the extraneous objects
+	// in the destination are only removed if DeleteExtraneous is set
+	SyncMirror = "mirror"
+	// SyncCopyNew only copies the objects missing in the destination
+	SyncCopyNew = "copy_new"
+	// SyncBidirectional copies the missing objects both ways and the newer one wins if both exist.
+	// Removals are not propagated, since the previous state of the two sides is not kept
+	SyncBidirectional = "bidirectional"
+)
+
+type SyncJob struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name" binding:"required"`
+	SrcPath string `json:"src_path" gorm:"type:text" binding:"required"`
+	DstPath string `json:"dst_path" gorm:"type:text" binding:"required"`
+	Mode    string `json:"mode"`
+	// Cron is a 5 fields cron expression, empty means the job only runs manually
+	Cron string `json:"cron"`
+	// DeleteExtraneous is only allowed in the SyncMirror mode
+	DeleteExtraneous bool      `json:"delete_extraneous"`
+	Disabled         bool      `json:"disabled"`
+	LastRunAt        time.Time `json:"last_run_at"`
+	LastResult       string    `json:"last_result" gorm:"type:text"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// TrashItem is an object moved into the trash, the object itself is at TrashDir/Name
+type TrashItem struct {
+	ID string `json:"id" gorm:"type:char(16);primaryKey"`
+	// Path is the original path of the object
+	Path      string    `json:"path" gorm:"type:text"`
+	TrashDir  string    `json:"trash_dir" gorm:"type:text"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	IsDir     bool      `json:"is_dir"`
+	Username  string    `json:"username"`
+	RemovedAt time.Time `json:"removed_at" gorm:"index"`
+}
+
+type TrashItemFilter struct {
+	// Path matches the items whose original path starts with it
+	Path     string `json:"path" form:"path"`
+	Username string `json:"username" form:"username"`
+}
@@ -55,11 +55,25 @@ type User struct {
 	//   12: can read archives
 	//   13: can decompress archives
 	//   14: can share
+	//   15: s3 read
+	//   16: s3 write
 	Permission int32  `json:"permission"`
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
 	AllowLdap  bool   `json:"allow_ldap" gorm:"default:true"`
+	// Quota is the max bytes the user may upload in total and MaxFileSize is the max bytes of a single upload,
+	// 0 means using the default of the role and a negative value means unlimited
+	Quota       int64 `json:"quota"`
+	MaxFileSize int64 `json:"max_file_size"`
+	// AdminToken is set on the admin downgraded by an api token, the limits of the admin still apply
+	AdminToken bool `json:"-" gorm:"-"`
+}
+
+// UserUsage is kept out of User, so saving a cached or submitted user never overwrites the usage
+type UserUsage struct {
+	UserID    uint  `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	UsedBytes int64 `json:"used_bytes"`
 }
 
 func (u *User) IsGuest() bool {
@@ -211,6 +225,22 @@ func (u *User) CanShare() bool {
 	return CanShare(u.Permission)
 }
 
+func CanS3Read(permission int32) bool {
+	return (permission>>15)&1 == 1
+}
+
+func (u *User) CanS3Read() bool {
+	return CanS3Read(u.Permission)
+}
+
+func CanS3Write(permission int32) bool {
+	return (permission>>16)&1 == 1
+}
+
+func (u *User) CanS3Write() bool {
+	return CanS3Write(u.Permission)
+}
+
 func (u *User) JoinPath(reqPath string) (string, error) {
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
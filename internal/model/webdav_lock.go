@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type WebdavLock struct {
+	Token     string `json:"token" gorm:"primaryKey;size:64"`
+	Root      string `json:"root" gorm:"type:text"`
+	ZeroDepth bool   `json:"zero_depth"`
+	OwnerXML  string `json:"owner_xml" gorm:"type:text"`
+	// Timeout in seconds, negative means infinite
+	Timeout int64 `json:"timeout"`
+	// ExpiresAt is nil if the lock never expires
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"`
+	// Temporary locks are taken while serving a request, they are left only if the server stopped meanwhile
+	Temporary bool `json:"temporary"`
+	// Instance is the conf.Config.InstanceID of the server taking the lock
+	Instance  string    `json:"instance" gorm:"size:32"`
+	CreatedAt time.Time `json:"created_at"`
+}
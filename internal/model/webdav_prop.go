@@ -0,0 +1,12 @@
+package model
+
+// WebdavProp is a dead property set by PROPPATCH, keyed by the virtual path of the object
+type WebdavProp struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Path  string `json:"path" gorm:"index"`
+	Space string `json:"space"`
+	Local string `json:"local"`
+	Lang  string `json:"lang"`
+	// InnerXML is the value of the property as is
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
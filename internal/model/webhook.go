@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type Webhook struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name"`
+	URL  string `json:"url" gorm:"type:text" binding:"required"`
+	// Secret is used to sign the payload with HMAC-SHA256, no signature is sent if it is empty
+	Secret string `json:"secret"`
+	// Events is a comma separated list of the subscribed events, empty means all events
+	Events string `json:"events"`
+	// Paths is a newline separated list of path globs, empty means all paths.
+	// Events without any path, e.g. task events, are not filtered by it
+	Paths    string `json:"paths" gorm:"type:text"`
+	MaxRetry int    `json:"max_retry"`
+	Disabled bool   `json:"disabled"`
+}
+
+type WebhookDelivery struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WebhookID  uint      `json:"webhook_id" gorm:"index"`
+	Event      string    `json:"event"`
+	Payload    string    `json:"payload" gorm:"type:text"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -191,6 +192,7 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, name string, modTime time
 
 	if r.Method != "HEAD" {
 		written, err := utils.CopyWithBufferN(w, sendContent, sendSize)
+		metrics.AddTransferredBytes("serve", written)
 		if err != nil {
 			if errors.Is(context.Cause(ctx), context.Canceled) {
 				return nil
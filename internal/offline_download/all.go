@@ -12,4 +12,5 @@ import (
 	_ "github.com/OpenListTeam/OpenList/v4/internal/offline_download/thunder_browser"
 	_ "github.com/OpenListTeam/OpenList/v4/internal/offline_download/thunderx"
 	_ "github.com/OpenListTeam/OpenList/v4/internal/offline_download/transmission"
+	_ "github.com/OpenListTeam/OpenList/v4/internal/offline_download/ytdlp"
 )
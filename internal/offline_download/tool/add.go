@@ -135,6 +135,11 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 		} else {
 			tempDir = filepath.Join(setting.GetStr(conf.ThunderXTempDir), uid)
 		}
+	case "yt-dlp":
+		// the url is a page rather than the file, so the media can only be uploaded after downloading
+		if deletePolicy == UploadDownloadStream {
+			deletePolicy = DeleteAlways
+		}
 	}
 
 	taskCreator, _ := ctx.Value(conf.UserKey).(*model.User) // taskCreator is nil when convert failed
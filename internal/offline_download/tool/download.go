@@ -211,6 +211,14 @@ func (t *DownloadTask) Transfer() error {
 	return transferStd(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
 }
 
+func (t *DownloadTask) OnSucceeded() {
+	task.HandleStateHook(t, true)
+}
+
+func (t *DownloadTask) OnFailed() {
+	task.HandleStateHook(t, false)
+}
+
 func (t *DownloadTask) GetName() string {
 	return fmt.Sprintf("download %s to (%s)", t.Url, t.DstDirPath)
 }
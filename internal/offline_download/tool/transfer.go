@@ -13,6 +13,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
@@ -73,7 +74,7 @@ func (t *TransferTask) Run() error {
 				Mimetype: mimetype,
 				Closers:  utils.NewClosers(r),
 			}
-			return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, s, t.SetProgress)
+			return quota.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, s, t.SetProgress)
 		}
 		return transferStdPath(t)
 	}
@@ -96,6 +97,7 @@ func (t *TransferTask) OnSucceeded() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	task.HandleStateHook(t, true)
 }
 
 func (t *TransferTask) OnFailed() {
@@ -107,6 +109,7 @@ func (t *TransferTask) OnFailed() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	task.HandleStateHook(t, false)
 }
 
 func (t *TransferTask) SetRetry(retry int, maxRetry int) {
@@ -217,7 +220,7 @@ func transferStdFile(t *TransferTask) error {
 		Closers:  utils.NewClosers(rc),
 	}
 	t.SetTotalBytes(info.Size())
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, s, t.SetProgress)
+	return quota.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, s, t.SetProgress)
 }
 
 func removeStdTemp(t *TransferTask) {
@@ -243,6 +246,9 @@ func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 	if err != nil {
 		return errors.WithMessagef(err, "failed list src [%s] objs", tempDir)
 	}
+	if utils.PathEqual(srcObjActualPath, "/") {
+		objs = op.HideTrashDir(objs)
+	}
 	taskCreator, _ := ctx.Value(conf.UserKey).(*model.User) // taskCreator is nil when convert failed
 	for _, obj := range objs {
 		t := &TransferTask{
@@ -279,6 +285,9 @@ func transferObjPath(t *TransferTask) error {
 		if err != nil {
 			return errors.WithMessagef(err, "failed list src [%s] objs", t.SrcActualPath)
 		}
+		if utils.PathEqual(t.SrcActualPath, "/") {
+			objs = op.HideTrashDir(objs)
+		}
 		dstDirActualPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
 		task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.DstPathToHook(dstDirActualPath))
 		for _, obj := range objs {
@@ -329,7 +338,7 @@ func transferObjFile(t *TransferTask) error {
 		return errors.WithMessagef(err, "failed get [%s] stream", t.SrcActualPath)
 	}
 	t.SetTotalBytes(ss.GetSize())
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	return quota.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, ss, t.SetProgress)
 }
 
 func removeObjTemp(t *TransferTask) {
@@ -0,0 +1,86 @@
+package ytdlp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// progressRegexp matches the progress lines of yt-dlp, e.g.
+// [download]  45.3% of ~  10.00MiB at  1.00MiB/s ETA 00:05 (frag 3/10)
+var progressRegexp = regexp.MustCompile(`^\[download\]\s+([\d.]+)%\s+of\s+~?\s*([\d.]+)\s*([KMGTP]?i?B)`)
+
+var units = map[string]float64{
+	"B":   1,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"PB":  1e15,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+	"PiB": 1 << 50,
+}
+
+// parseProgress returns the progress in percent and the total bytes of a progress line
+func parseProgress(line string) (float64, int64, bool) {
+	m := progressRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return 0, 0, false
+	}
+	progress, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	size, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	unit, ok := units[m[3]]
+	if !ok {
+		return 0, 0, false
+	}
+	return progress, int64(size * unit), true
+}
+
+// splitArgs splits the arguments by spaces like a shell, except that only the quotes are special
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		quote   rune
+		started bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, started = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if started {
+				args = append(args, cur.String())
+				cur.Reset()
+				started = false
+			}
+		default:
+			cur.WriteRune(r)
+			started = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.Errorf("unclosed quote %c", quote)
+	}
+	if started {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package ytdlp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the downloader in its own process group, and kills the whole group on cancel,
+// so the processes started by the downloader, e.g. ffmpeg, are killed as well
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
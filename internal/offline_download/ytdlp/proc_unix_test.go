@@ -0,0 +1,63 @@
+//go:build !windows
+
+package ytdlp
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+)
+
+// alive reports whether the process is running, a zombie left for its parent counts as dead
+func alive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	_, after, _ := strings.Cut(string(stat), ") ")
+	return !strings.HasPrefix(after, "Z")
+}
+
+func TestRemoveKillsProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "child.pid")
+	bin := filepath.Join(dir, "yt-dlp")
+	// the fake downloader starts a child like ffmpeg and waits for it
+	script := "#!/bin/sh\nsleep 60 &\necho $! > " + pidFile + "\nwait\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	y := &YtDlp{path: bin}
+	gid, err := y.AddURL(&tool.AddUrlArgs{Url: "https://example.com", UID: "group", TempDir: filepath.Join(dir, "download"), Signal: make(chan int, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pid int
+	for i := 0; i < 100 && pid == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		data, _ := os.ReadFile(pidFile)
+		pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+	if pid == 0 {
+		t.Fatal("the child is not started")
+	}
+	if err = y.Remove(&tool.DownloadTask{GID: gid}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && alive(pid); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if alive(pid) {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+		t.Error("the child of the downloader is not killed")
+	}
+}
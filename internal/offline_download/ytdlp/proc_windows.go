@@ -0,0 +1,8 @@
+//go:build windows
+
+package ytdlp
+
+import "os/exec"
+
+// setProcessGroup does nothing on windows, only the downloader itself is killed on cancel
+func setProcessGroup(cmd *exec.Cmd) {}
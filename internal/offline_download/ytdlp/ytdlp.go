@@ -0,0 +1,215 @@
+// Package ytdlp downloads media pages by an external downloader command with the options of yt-dlp,
+// e.g. yt-dlp itself or youtube-dl, into the temp dir, then the files are transferred to the storage.
+package ytdlp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type YtDlp struct {
+	path  string
+	mu    sync.Mutex
+	procs map[string]*process
+}
+
+func (y *YtDlp) Name() string {
+	return "yt-dlp"
+}
+
+func (y *YtDlp) Items() []model.SettingItem {
+	return []model.SettingItem{
+		{Key: conf.YtDlpPath, Value: "yt-dlp", Type: conf.TypeString, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `the downloader command, the name in PATH or the path of the binary`},
+		{Key: conf.YtDlpFormat, Value: "", Type: conf.TypeString, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `the format passed by -f, e.g. bestvideo+bestaudio/best, empty means the default of the downloader`},
+		{Key: conf.YtDlpArgs, Value: "", Type: conf.TypeString, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `extra arguments separated by spaces, quote an argument containing spaces, e.g. --no-playlist --proxy socks5://127.0.0.1:1080`},
+	}
+}
+
+func (y *YtDlp) Init() (string, error) {
+	y.path = ""
+	path := setting.GetStr(conf.YtDlpPath)
+	if path == "" {
+		return "", errors.New("the yt-dlp path is empty")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed run %s", path)
+	}
+	y.path = path
+	version := strings.TrimSpace(string(out))
+	log.Infof("using yt-dlp version: %s", version)
+	return fmt.Sprintf("yt-dlp version: %s", version), nil
+}
+
+func (y *YtDlp) IsReady() bool {
+	return y.path != ""
+}
+
+// AddURL starts the downloader in the temp dir, the id of the download is the uid of the task
+func (y *YtDlp) AddURL(args *tool.AddUrlArgs) (string, error) {
+	extra, err := splitArgs(setting.GetStr(conf.YtDlpArgs))
+	if err != nil {
+		return "", errors.WithMessage(err, "invalid yt-dlp args")
+	}
+	if err = os.MkdirAll(args.TempDir, os.ModePerm); err != nil {
+		return "", errors.WithStack(err)
+	}
+	cmdArgs := []string{"--newline", "--no-colors", "--no-part", "-o", filepath.Join(args.TempDir, "%(title)s [%(id)s].%(ext)s")}
+	if format := setting.GetStr(conf.YtDlpFormat); format != "" {
+		cmdArgs = append(cmdArgs, "-f", format)
+	}
+	cmdArgs = append(cmdArgs, extra...)
+	cmdArgs = append(cmdArgs, "--", args.Url)
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, y.path, cmdArgs...)
+	cmd.Dir = args.TempDir
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return "", errors.WithStack(err)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err = cmd.Start(); err != nil {
+		cancel()
+		return "", errors.Wrapf(err, "failed start %s", y.path)
+	}
+	gid := args.UID
+	if gid == "" {
+		gid = uuid.NewString()
+	}
+	p := &process{cancel: cancel}
+	y.mu.Lock()
+	if y.procs == nil {
+		y.procs = make(map[string]*process)
+	}
+	y.procs[gid] = p
+	y.mu.Unlock()
+	go func() {
+		p.parse(stdout)
+		p.finish(cmd.Wait())
+		// wake up the task, it polls the status anyway if it is not waiting
+		select {
+		case args.Signal <- 1:
+		default:
+		}
+	}()
+	return gid, nil
+}
+
+func (y *YtDlp) get(gid string) (*process, bool) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	p, ok := y.procs[gid]
+	return p, ok
+}
+
+func (y *YtDlp) Remove(task *tool.DownloadTask) error {
+	y.mu.Lock()
+	p, ok := y.procs[task.GID]
+	delete(y.procs, task.GID)
+	y.mu.Unlock()
+	if ok {
+		p.cancel()
+	}
+	return nil
+}
+
+func (y *YtDlp) Status(task *tool.DownloadTask) (*tool.Status, error) {
+	p, ok := y.get(task.GID)
+	if !ok {
+		// the processes are not kept over restarts, the task fails instead of polling forever,
+		// and it downloads again by a retry
+		return &tool.Status{Err: errors.Errorf("the download %s is not running, e.g. interrupted by a restart, retry the task to download again", task.GID)}, nil
+	}
+	s := p.status()
+	if s.Completed || s.Err != nil {
+		y.mu.Lock()
+		delete(y.procs, task.GID)
+		y.mu.Unlock()
+	}
+	return s, nil
+}
+
+func (y *YtDlp) Run(task *tool.DownloadTask) error {
+	return errs.NotSupport
+}
+
+// process is a running downloader, its output is parsed into the status
+type process struct {
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	tool.Status
+	// lastErr is the last error line of the output
+	lastErr string
+	done    bool
+}
+
+func (p *process) parse(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		p.mu.Lock()
+		if progress, total, ok := parseProgress(line); ok {
+			p.Progress, p.TotalBytes = progress, total
+		} else if strings.HasPrefix(line, "ERROR:") {
+			p.lastErr = strings.TrimSpace(strings.TrimPrefix(line, "ERROR:"))
+		}
+		p.Status.Status = line
+		p.mu.Unlock()
+	}
+}
+
+func (p *process) finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = true
+	p.cancel()
+	if err == nil {
+		p.Completed = true
+		p.Progress = 100
+		return
+	}
+	if p.lastErr != "" {
+		err = errors.New(p.lastErr)
+	}
+	p.Err = err
+}
+
+func (p *process) status() *tool.Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.Status
+	if !p.done {
+		s.Status = "yt-dlp: " + s.Status
+	}
+	return &s
+}
+
+var _ tool.Tool = (*YtDlp)(nil)
+
+func init() {
+	tool.Tools.Add(&YtDlp{})
+}
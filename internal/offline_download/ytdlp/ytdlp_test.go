@@ -0,0 +1,148 @@
+package ytdlp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line     string
+		progress float64
+		total    int64
+		ok       bool
+	}{
+		{"[download]  45.3% of ~  10.00MiB at  1.00MiB/s ETA 00:05 (frag 3/10)", 45.3, 10 << 20, true},
+		{"[download] 100% of  512.00KiB in 00:00:01 at 400.00KiB/s", 100, 512 << 10, true},
+		{"[download]   0.0% of 2.50GB at Unknown B/s ETA Unknown", 0, 2500000000, true},
+		{"[download] Destination: /tmp/a.mp4", 0, 0, false},
+		{"[youtube] abc: Downloading webpage", 0, 0, false},
+	}
+	for _, tt := range tests {
+		progress, total, ok := parseProgress(tt.line)
+		if progress != tt.progress || total != tt.total || ok != tt.ok {
+			t.Errorf("parseProgress(%q) = %v, %v, %v", tt.line, progress, total, ok)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(` --no-playlist  -o "%(title)s [x].%(ext)s" --proxy '' `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--no-playlist", "-o", "%(title)s [x].%(ext)s", "--proxy", ""}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("splitArgs = %q, want %q", args, want)
+	}
+	if _, err = splitArgs(`--proxy "a`); err == nil {
+		t.Error("unclosed quote is accepted")
+	}
+}
+
+// fakeYtDlp writes the url into a file of the output dir after printing the progress, or fails for the url fail
+const fakeYtDlp = `#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo 2025.01.01
+	exit 0
+fi
+case " $* " in
+*" -f best --no-playlist "*) ;;
+*) echo "ERROR: unexpected arguments $*" >&2; exit 2 ;;
+esac
+for last; do :; done
+if [ "$last" = "fail" ]; then
+	echo "ERROR: Unsupported URL: fail" >&2
+	exit 1
+fi
+while [ "$1" != "-o" ]; do shift; done
+dir=$(dirname "$2")
+echo "[download] Destination: $dir/video.mp4"
+echo "[download]  50.0% of 2.00KiB at 1.00KiB/s ETA 00:01"
+echo "$last" > "$dir/video.mp4"
+echo "[download] 100% of 2.00KiB in 00:00:01 at 2.00KiB/s"
+`
+
+func TestDownload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake downloader is a shell script")
+	}
+	bin := filepath.Join(t.TempDir(), "yt-dlp")
+	if err := os.WriteFile(bin, []byte(fakeYtDlp), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	y := &YtDlp{}
+	items := y.Items()
+	for i := range items {
+		switch items[i].Key {
+		case conf.YtDlpPath:
+			items[i].Value = bin
+		case conf.YtDlpFormat:
+			items[i].Value = "best"
+		case conf.YtDlpArgs:
+			items[i].Value = "--no-playlist"
+		}
+	}
+	if err := op.SaveSettingItems(items); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := y.Init(); err != nil || !y.IsReady() || version != "yt-dlp version: 2025.01.01" {
+		t.Fatalf("Init() = %q, %v", version, err)
+	}
+	download := func(url string) (*tool.Status, string) {
+		dir := filepath.Join(t.TempDir(), "download")
+		signal := make(chan int, 1)
+		gid, err := y.AddURL(&tool.AddUrlArgs{Url: url, UID: url, TempDir: dir, Signal: signal})
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-signal:
+		case <-time.After(10 * time.Second):
+			t.Fatal("the download is not finished")
+		}
+		s, err := y.Status(&tool.DownloadTask{GID: gid})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, dir
+	}
+
+	s, dir := download("https://example.com/watch?v=1")
+	if !s.Completed || s.Err != nil || s.Progress != 100 || s.TotalBytes != 2048 {
+		t.Errorf("status = %+v", s)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "video.mp4"))
+	if err != nil || string(data) != "https://example.com/watch?v=1\n" {
+		t.Errorf("downloaded %q, %v", data, err)
+	}
+	// the finished download is not kept, the status of a download not running is an error
+	if s, err = y.Status(&tool.DownloadTask{GID: "https://example.com/watch?v=1"}); err != nil || s.Err == nil {
+		t.Errorf("status of the finished download = %+v, %v", s, err)
+	}
+
+	s, _ = download("fail")
+	if s.Completed || s.Err == nil || s.Err.Error() != "Unsupported URL: fail" {
+		t.Errorf("status = %+v", s)
+	}
+}
@@ -0,0 +1,102 @@
+package op
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// APITokenPrefix starts every personal api token, so it can be told apart from the jwt tokens
+const APITokenPrefix = "olt_"
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// CreateAPIToken generates the token and saves its hash, the returned token can't be got again
+func CreateAPIToken(t *model.APIToken) (string, error) {
+	token := APITokenPrefix + random.String(40)
+	t.Prefix = token[:len(APITokenPrefix)+8]
+	t.TokenHash = hashAPIToken(token)
+	t.CreatedAt = time.Now()
+	t.LastUsedAt = nil
+	if err := db.CreateAPIToken(t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// APITokenS3Secret returns the S3 secret access key of the token, whose access key id is the prefix.
+// It is derived from the token hash and the jwt secret, so the plain token needs not be stored
+func APITokenS3Secret(t *model.APIToken) string {
+	mac := hmac.New(sha256.New, []byte(conf.Conf.JwtSecret))
+	mac.Write([]byte(t.TokenHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokensByUserId(userId, pageIndex, pageSize)
+}
+
+func DeleteAPITokenByIdAndUserId(id, userId uint) error {
+	t, err := db.GetAPITokenById(id)
+	if err != nil {
+		return err
+	}
+	if t.UserID != userId {
+		return errors.New("token not found")
+	}
+	return db.DeleteAPITokenById(id)
+}
+
+// GetUserByAPIToken returns the owner of the token restricted by the token
+func GetUserByAPIToken(token string) (*model.User, *model.APIToken, error) {
+	t, err := db.GetAPITokenByHash(hashAPIToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyAPIToken(t)
+}
+
+// GetUserByAPITokenPrefix is the same as GetUserByAPIToken but finds the token by its prefix,
+// the caller must verify the request with APITokenS3Secret
+func GetUserByAPITokenPrefix(prefix string) (*model.User, *model.APIToken, error) {
+	t, err := db.GetAPITokenByPrefix(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyAPIToken(t)
+}
+
+func applyAPIToken(t *model.APIToken) (*model.User, *model.APIToken, error) {
+	if t.Expired() {
+		return nil, nil, errors.New("token is expired")
+	}
+	user, err := GetUserById(t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, errors.New("user is disabled")
+	}
+	// don't write the database on every request
+	now := time.Now()
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > time.Minute {
+		t.LastUsedAt = &now
+		_ = db.UpdateAPITokenLastUsed(t.ID, now)
+	}
+	return t.Apply(user), t, nil
+}
@@ -3,5 +3,6 @@ package op
 const (
 	WORK     = "work"
 	DISABLED = "disabled"
+	DEGRADED = "degraded"
 	RootName = "root"
 )
@@ -10,6 +10,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
@@ -40,12 +41,15 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 			objs := dirCache.GetSortedObjects(storage)
 			if resultValidator != nil {
 				if err := resultValidator(objs); err == nil {
+					metrics.ObserveDirCache(true)
 					return objs, nil
 				}
 			} else {
+				metrics.ObserveDirCache(true)
 				return objs, nil
 			}
 		}
+		metrics.ObserveDirCache(false)
 	}
 
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
@@ -57,7 +61,9 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !dir.IsDir() {
 			return nil, errors.WithStack(errs.NotFolder)
 		}
+		start := time.Now()
 		files, err := storage.List(ctx, dir, args)
+		metrics.ObserveStorageRequest(storage.GetStorage().MountPath, "list", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -256,7 +262,9 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 			return nil, errors.WithStack(errs.NotFile)
 		}
 
+		start := time.Now()
 		link, err := storage.Link(ctx, file, args)
+		metrics.ObserveStorageRequest(storage.GetStorage().MountPath, "link", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -582,6 +590,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
 	if err == nil {
+		if ctx.Value(conf.NoOverwriteKey) != nil {
+			return errors.WithStack(errs.ObjectAlreadyExists)
+		}
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
 			if err != nil {
@@ -618,6 +629,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	}
 
 	var newObj model.Obj
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.PutResult:
 		newObj, err = s.Put(ctx, parentDir, file, up)
@@ -626,6 +638,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	default:
 		return errs.NotImplement
 	}
+	metrics.ObserveStorageRequest(storage.GetStorage().MountPath, "put", start, err)
 	if err == nil {
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
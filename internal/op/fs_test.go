@@ -0,0 +1,55 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+func TestPutNoOverwrite(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/put_no_overwrite",
+		Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := op.GetStorageByMountPath("/put_no_overwrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(ctx context.Context, name string) error {
+		return op.Put(ctx, storage, "/", &stream.FileStream{
+			Obj:    &model.Object{Name: name, Size: 3},
+			Reader: strings.NewReader("new"),
+		}, nil)
+	}
+	ctx := context.WithValue(context.Background(), conf.NoOverwriteKey, struct{}{})
+	if err = put(ctx, "a.txt"); !errors.Is(err, errs.ObjectAlreadyExists) {
+		t.Errorf("put existing file = %v, want ObjectAlreadyExists", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "old" {
+		t.Errorf("the existing file is overwritten: %q", data)
+	}
+	if err = put(ctx, "b.txt"); err != nil {
+		t.Errorf("put new file = %v", err)
+	}
+	if err = put(context.Background(), "a.txt"); err != nil {
+		t.Errorf("put overwriting = %v", err)
+	}
+}
@@ -94,3 +94,7 @@ func GetMetaById(id uint) (*model.Meta, error) {
 func GetMetas(pageIndex, pageSize int) (metas []model.Meta, count int64, err error) {
 	return db.GetMetas(pageIndex, pageSize)
 }
+
+func ClearMetaCache() {
+	metaCache.Clear()
+}
@@ -0,0 +1,74 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// S3KeyPrefix starts every access key id of the users, so it can be told apart from the static one
+const S3KeyPrefix = "OL"
+
+func IsS3Key(accessKeyId string) bool {
+	return strings.HasPrefix(accessKeyId, S3KeyPrefix) && len(accessKeyId) == len(S3KeyPrefix)+18
+}
+
+// CreateS3Key generates the access key id and the secret of the key
+func CreateS3Key(k *model.S3Key) error {
+	k.AccessKeyID = S3KeyPrefix + strings.ToUpper(random.String(18))
+	k.SecretAccessKey = random.String(40)
+	k.CreatedAt = time.Now()
+	return db.CreateS3Key(k)
+}
+
+func GetS3KeysByUserId(userId uint, pageIndex, pageSize int) ([]model.S3Key, int64, error) {
+	return db.GetS3KeysByUserId(userId, pageIndex, pageSize)
+}
+
+func DeleteS3KeyByIdAndUserId(id, userId uint) error {
+	k, err := db.GetS3KeyById(id)
+	if err != nil {
+		return err
+	}
+	if k.UserID != userId {
+		return errors.New("key not found")
+	}
+	return db.DeleteS3KeyById(id)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return db.DeleteS3KeyById(id)
+}
+
+func HasS3Keys() bool {
+	count, err := db.CountS3Keys()
+	return err == nil && count > 0
+}
+
+// GetS3Credential returns the user and the secret of the access key id, which is either
+// the access key of a user or the prefix of an api token
+func GetS3Credential(accessKeyId string) (*model.User, string, error) {
+	if IsAPIToken(accessKeyId) {
+		user, t, err := GetUserByAPITokenPrefix(accessKeyId)
+		if err != nil {
+			return nil, "", err
+		}
+		return user, APITokenS3Secret(t), nil
+	}
+	k, err := db.GetS3KeyByAccessKeyId(accessKeyId)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := GetUserById(k.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.Disabled {
+		return nil, "", errors.New("user is disabled")
+	}
+	return user, k.SecretAccessKey, nil
+}
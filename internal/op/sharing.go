@@ -4,8 +4,10 @@ import (
 	"fmt"
 	stdpath "path"
 	"strings"
+	"sync/atomic"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -92,8 +94,18 @@ func GetSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath strin
 		return "", errors.New("cannot get actual path of an invalid sharing")
 	}
 	if len(sharing.Files) == 1 {
-		return stdpath.Join(sharing.Files[0], path), nil
+		unwrapPath = stdpath.Join(sharing.Files[0], path)
+	} else if unwrapPath, err = getSharingChildPath(sharing, path); err != nil {
+		return "", err
 	}
+	// the trash of a storage is never shared, even if the whole storage is
+	if IsTrashMountPath(unwrapPath) {
+		return "", errors.WithStack(errs.ObjectNotFound)
+	}
+	return unwrapPath, nil
+}
+
+func getSharingChildPath(sharing *model.Sharing, path string) (string, error) {
 	path = utils.FixAndCleanPath(path)[1:]
 	if len(path) == 0 {
 		return "", errors.New("cannot get actual path of a sharing root path")
@@ -133,11 +145,30 @@ func UpdateSharing(sharing *model.Sharing, skipMarshal ...bool) (err error) {
 	return db.UpdateSharing(sharing.SharingDB)
 }
 
+// AddSharingBytesServed adds the downloaded bytes to the sharing and its cache
+func AddSharingBytesServed(sharing *model.Sharing, n int64) error {
+	atomic.AddInt64(&sharing.BytesServed, n)
+	return db.AddSharingBytesServed(sharing.ID, n)
+}
+
+// DisableSharing disables the sharing without changing the cached one, which may be in use by the downloads
+func DisableSharing(sid string) error {
+	sharingCache.Del(sid)
+	return db.DisableSharing(sid)
+}
+
 func DeleteSharing(sid string) error {
 	sharingCache.Del(sid)
+	if err := db.DeleteShareAccessesBySharingId(sid); err != nil {
+		return errors.WithMessage(err, "failed delete share accesses")
+	}
 	return db.DeleteSharingById(sid)
 }
 
 func DeleteSharingsByCreatorId(creatorId uint) error {
 	return db.DeleteSharingsByCreatorId(creatorId)
 }
+
+func ClearSharingCache() {
+	sharingCache.Clear()
+}
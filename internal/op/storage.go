@@ -13,6 +13,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
@@ -56,6 +57,9 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 		return 0, errors.WithMessage(err, "failed get driver new")
 	}
 	storageDriver := driverNew()
+	if err = checkTrashStorage(&storage); err != nil {
+		return 0, err
+	}
 	// insert storage to database
 	err = db.CreateStorage(&storage)
 	if err != nil {
@@ -229,6 +233,9 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	}
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+	if err = checkTrashStorage(&storage); err != nil {
+		return err
+	}
 	err = db.UpdateStorage(&storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
@@ -257,6 +264,37 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	return err
 }
 
+// ReloadStorage drops the storage and initializes it again with its current configuration,
+// which includes the tokens refreshed by the driver
+func ReloadStorage(ctx context.Context, storageDriver driver.Driver) error {
+	storage := *storageDriver.GetStorage()
+	if err := storageDriver.Drop(ctx); err != nil {
+		log.Warnf("failed drop storage [%s] before reload: %+v", storage.MountPath, err)
+	}
+	Cache.DeleteDirectoryTree(storageDriver, "/")
+	Cache.InvalidateStorageDetails(storageDriver)
+	err := initStorage(ctx, storage, storageDriver)
+	go callStorageHooks("update", storageDriver)
+	return err
+}
+
+// UnloadStorage drops the storage and removes it from the memory, the storage in the database is kept
+func UnloadStorage(ctx context.Context, storageDriver driver.Driver) error {
+	var dropErr error
+	// drop the storage in the driver
+	if err := storageDriver.Drop(ctx); err != nil {
+		dropErr = errors.Wrapf(err, "failed drop storage")
+	}
+	// delete the storage in the memory
+	mountPath := storageDriver.GetStorage().MountPath
+	storagesMap.Delete(mountPath)
+	Cache.DeleteDirectoryTree(storageDriver, "/")
+	Cache.InvalidateStorageDetails(storageDriver)
+	metrics.DeleteStorage(mountPath)
+	go callStorageHooks("del", storageDriver)
+	return dropErr
+}
+
 func DeleteStorageById(ctx context.Context, id uint) error {
 	storage, err := db.GetStorageById(id)
 	if err != nil {
@@ -268,15 +306,7 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 		if err != nil {
 			return errors.WithMessage(err, "failed get storage driver")
 		}
-		// drop the storage in the driver
-		if err := storageDriver.Drop(ctx); err != nil {
-			dropErr = errors.Wrapf(err, "failed drop storage")
-		}
-		// delete the storage in the memory
-		storagesMap.Delete(storage.MountPath)
-		Cache.DeleteDirectoryTree(storageDriver, "/")
-		Cache.InvalidateStorageDetails(storageDriver)
-		go callStorageHooks("del", storageDriver)
+		dropErr = UnloadStorage(ctx, storageDriver)
 	}
 	// delete the storage in the database
 	if err := db.DeleteStorageById(id); err != nil {
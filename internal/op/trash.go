@@ -0,0 +1,54 @@
+package op
+
+import (
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// TrashDirName is the hidden folder in the root of a storage keeping the removed objects
+const TrashDirName = ".openlist_trash"
+
+// IsTrashPath reports whether the actual path is the trash folder or inside it
+func IsTrashPath(actualPath string) bool {
+	actualPath = utils.FixAndCleanPath(actualPath)
+	trashPath := stdpath.Join("/", TrashDirName)
+	return actualPath == trashPath || strings.HasPrefix(actualPath, trashPath+"/")
+}
+
+// IsTrashMountPath is IsTrashPath for a mount path
+func IsTrashMountPath(path string) bool {
+	_, actualPath, err := GetStorageAndActualPath(path)
+	return err == nil && IsTrashPath(actualPath)
+}
+
+// HideTrashDir removes the trash folder from the objects listed in the root of a storage
+func HideTrashDir(objs []model.Obj) []model.Obj {
+	for i, obj := range objs {
+		if obj.GetName() == TrashDirName {
+			res := make([]model.Obj, 0, len(objs)-1)
+			res = append(res, objs[:i]...)
+			return append(res, objs[i+1:]...)
+		}
+	}
+	return objs
+}
+
+// checkTrashStorage makes sure the trash storage is the mount path of a storage, the trash is only hidden
+// in the root of a storage, so a path inside a storage would leave the trash visible to everyone
+func checkTrashStorage(storage *model.Storage) error {
+	if storage.TrashStorage == "" {
+		return nil
+	}
+	storage.TrashStorage = utils.FixAndCleanPath(storage.TrashStorage)
+	if storage.TrashStorage == storage.MountPath {
+		return nil
+	}
+	if _, err := GetStorageByMountPath(storage.TrashStorage); err != nil {
+		return errors.Errorf("trash storage %s is not the mount path of a storage", storage.TrashStorage)
+	}
+	return nil
+}
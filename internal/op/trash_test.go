@@ -0,0 +1,54 @@
+package op_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestIsTrashPath(t *testing.T) {
+	tests := map[string]bool{
+		"/.openlist_trash":           true,
+		"/.openlist_trash/":          true,
+		"/.openlist_trash/abc/a.txt": true,
+		"/":                          false,
+		"/.openlist_trash_bak":       false,
+		"/dir/.openlist_trash/abc":   false,
+		"/.openlist_trash/../docs/a": false,
+	}
+	for path, want := range tests {
+		if got := op.IsTrashPath(path); got != want {
+			t.Errorf("IsTrashPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestCheckTrashStorage(t *testing.T) {
+	root := t.TempDir()
+	create := func(mountPath, trashStorage string) error {
+		storage := model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+		}
+		storage.TrashStorage = trashStorage
+		_, err := op.CreateStorage(context.Background(), storage)
+		return err
+	}
+	if err := create("/trash_target", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := create("/trash_self", "/trash_self"); err != nil {
+		t.Errorf("the storage itself should be a valid trash storage: %v", err)
+	}
+	if err := create("/trash_other", "/trash_target/"); err != nil {
+		t.Errorf("a mount path should be a valid trash storage: %v", err)
+	}
+	// the trash would be listed to everyone in a sub folder
+	if err := create("/trash_sub", "/trash_target/sub"); err == nil {
+		t.Error("a path inside a storage should be rejected")
+	}
+}
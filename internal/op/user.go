@@ -128,3 +128,9 @@ func DelUserCache(username string) error {
 	Cache.DeleteUser(username)
 	return nil
 }
+
+// ClearUserCache drops all the cached users, e.g. after the users are replaced in the database
+func ClearUserCache() {
+	adminUser, guestUser = nil, nil
+	Cache.userCache.Clear()
+}
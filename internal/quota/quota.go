@@ -0,0 +1,207 @@
+package quota
+
+import (
+	"context"
+	"io"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Limits returns the effective quota and max file size of the user, 0 means unlimited
+func Limits(user *model.User) (quota, maxFileSize int64) {
+	quota, maxFileSize = user.Quota, user.MaxFileSize
+	var quotaKey, maxFileSizeKey string
+	switch user.Role {
+	case model.GENERAL:
+		// the defaults are for the general users, not the admins using an api token
+		if !user.AdminToken {
+			quotaKey, maxFileSizeKey = conf.DefaultUserQuota, conf.DefaultUserMaxFileSize
+		}
+	case model.GUEST:
+		quotaKey, maxFileSizeKey = conf.DefaultGuestQuota, conf.DefaultGuestMaxFileSize
+	}
+	if quota == 0 && quotaKey != "" {
+		quota = int64(setting.GetInt(quotaKey, 0))
+	}
+	if maxFileSize == 0 && maxFileSizeKey != "" {
+		maxFileSize = int64(setting.GetInt(maxFileSizeKey, 0))
+	}
+	return max(quota, 0), max(maxFileSize, 0)
+}
+
+type Usage struct {
+	Used        int64 `json:"used"`
+	Quota       int64 `json:"quota"`
+	MaxFileSize int64 `json:"max_file_size"`
+}
+
+func GetUsage(user *model.User) (*Usage, error) {
+	used, err := db.GetUserUsedBytes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	quota, maxFileSize := Limits(user)
+	return &Usage{Used: used, Quota: quota, MaxFileSize: maxFileSize}, nil
+}
+
+// Check returns errs.FileTooLarge or errs.QuotaExceeded if the user in ctx is not allowed to upload size bytes,
+// a negative size means unknown and only checks whether the quota is used up
+func Check(ctx context.Context, size int64) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return nil
+	}
+	quota, maxFileSize := Limits(user)
+	if maxFileSize > 0 && size > maxFileSize {
+		return errors.WithStack(errs.FileTooLarge)
+	}
+	if quota == 0 {
+		return nil
+	}
+	used, err := db.GetUserUsedBytes(user.ID)
+	if err != nil {
+		return err
+	}
+	if (size < 0 && used >= quota) || used+size > quota {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	return nil
+}
+
+// Limited reports whether the user in ctx has a quota
+func Limited(ctx context.Context) bool {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return false
+	}
+	quota, _ := Limits(user)
+	return quota > 0
+}
+
+// Reserve adds size bytes to the usage of the user in ctx if they fit in the quota. The check and the addition
+// are a single update of the database, so concurrent uploads can't exceed the quota together.
+// It returns errs.FileTooLarge or errs.QuotaExceeded.
+//
+// The usage counts the bytes uploaded, removing or overwriting a file gives nothing back,
+// since the uploader of a file is not recorded. The admins reset the usage of the users.
+func Reserve(ctx context.Context, size int64) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return nil
+	}
+	quota, maxFileSize := Limits(user)
+	if maxFileSize > 0 && size > maxFileSize {
+		return errors.WithStack(errs.FileTooLarge)
+	}
+	if size <= 0 {
+		return nil
+	}
+	if quota == 0 {
+		// the usage is kept even if unlimited, so it is right once a quota is set
+		return db.AddUserUsedBytes(user.ID, size)
+	}
+	reserved, err := db.ReserveUserUsedBytes(user.ID, size, quota)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	return nil
+}
+
+// Release subtracts the bytes reserved for a failed upload from the usage of the user in ctx
+func Release(ctx context.Context, size int64) {
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		release(user, size)
+	}
+}
+
+func release(user *model.User, size int64) {
+	if size <= 0 {
+		return
+	}
+	if err := db.SubUserUsedBytes(user.ID, size); err != nil {
+		log.Errorf("failed release usage of user [%s]: %+v", user.Username, err)
+	}
+}
+
+// Put is op.Put accounting the file to the user in ctx. The file of unknown size is cached first, and
+// the caching fails once it exceeds the max file size or the quota left.
+func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return op.Put(ctx, storage, dstDirPath, file, up)
+	}
+	if file.GetSize() < 0 {
+		if err := cacheWithinLimits(user, file); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	size := file.GetSize()
+	if err := Reserve(ctx, size); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := op.Put(ctx, storage, dstDirPath, file, up); err != nil {
+		release(user, size)
+		return err
+	}
+	return nil
+}
+
+func cacheWithinLimits(user *model.User, file model.FileStreamer) error {
+	quota, maxFileSize := Limits(user)
+	limit, limitErr := maxFileSize, errs.FileTooLarge
+	if quota > 0 {
+		used, err := db.GetUserUsedBytes(user.ID)
+		if err != nil {
+			return err
+		}
+		if left := max(quota-used, 0); limit == 0 || left < limit {
+			limit, limitErr = left, errs.QuotaExceeded
+		}
+	}
+	if s, ok := file.(*stream.FileStream); ok && (quota > 0 || maxFileSize > 0) {
+		s.Reader = LimitReader(s.Reader, limit, limitErr)
+	}
+	_, err := file.CacheFullAndWriter(nil, nil)
+	return err
+}
+
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+// LimitReader returns a reader of r failing with err once more than n bytes are read
+func LimitReader(r io.Reader, n int64, err error) io.Reader {
+	return &limitedReader{r: r, n: n, err: err}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errors.WithStack(l.err)
+	}
+	// read one more byte to tell whether the limit is exceeded
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errors.WithStack(l.err)
+	}
+	return n, err
+}
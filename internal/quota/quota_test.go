@@ -0,0 +1,88 @@
+package quota
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestReserve(t *testing.T) {
+	user := &model.User{ID: 100, Username: "quota", Role: model.ADMIN, Quota: 100, MaxFileSize: 60}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	used := func() int64 {
+		n, err := db.GetUserUsedBytes(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if err := Reserve(ctx, 61); !errors.Is(err, errs.FileTooLarge) {
+		t.Errorf("Reserve(61) = %v, want FileTooLarge", err)
+	}
+	if err := Reserve(ctx, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reserve(ctx, 50); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("Reserve(50) = %v, want QuotaExceeded", err)
+	}
+	if err := Reserve(ctx, 30); err != nil {
+		t.Errorf("Reserve(30) = %v", err)
+	}
+	if n := used(); n != 90 {
+		t.Errorf("used = %d, want 90", n)
+	}
+	Release(ctx, 40)
+	if n := used(); n != 50 {
+		t.Errorf("used = %d, want 50", n)
+	}
+	Release(ctx, 80)
+	if n := used(); n != 0 {
+		t.Errorf("used = %d, want 0", n)
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	data, err := io.ReadAll(LimitReader(strings.NewReader("0123456789"), 10, errs.QuotaExceeded))
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	_, err = io.ReadAll(LimitReader(strings.NewReader("0123456789"), 9, errs.QuotaExceeded))
+	if !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("err = %v, want QuotaExceeded", err)
+	}
+}
+
+func TestLimitsOfAdminToken(t *testing.T) {
+	err := op.SaveSettingItem(&model.SettingItem{Key: conf.DefaultUserQuota, Value: "1000", Type: conf.TypeNumber, Group: model.GLOBAL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer op.SaveSettingItem(&model.SettingItem{Key: conf.DefaultUserQuota, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL})
+	if quota, _ := Limits(&model.User{Role: model.GENERAL}); quota != 1000 {
+		t.Errorf("the general user should have the default quota, got %d", quota)
+	}
+	admin := &model.User{Role: model.ADMIN, Permission: 0x71FF}
+	token := (&model.APIToken{Permission: 0x71FF}).Apply(admin)
+	if quota, _ := Limits(token); quota != 0 {
+		t.Errorf("the token of the admin should keep the limits of the admin, got %d", quota)
+	}
+}
@@ -1,14 +1,21 @@
 package bleve
 
 import (
+	"os"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
 	log "github.com/sirupsen/logrus"
 )
 
 var config = searcher.Config{
-	Name: "bleve",
+	Name:    "bleve",
+	Content: true,
 }
 
 func Init(indexPath *string) (bleve.Index, error) {
@@ -16,26 +23,93 @@ func Init(indexPath *string) (bleve.Index, error) {
 	fileIndex, err := bleve.Open(*indexPath)
 	if err == bleve.ErrorIndexPathDoesNotExist {
 		log.Infof("Creating new index...")
-		indexMapping := bleve.NewIndexMapping()
-		searchNodeMapping := bleve.NewDocumentMapping()
-		searchNodeMapping.AddFieldMappingsAt("is_dir", bleve.NewBooleanFieldMapping())
-		// TODO: appoint analyzer
-		parentFieldMapping := bleve.NewTextFieldMapping()
-		searchNodeMapping.AddFieldMappingsAt("parent", parentFieldMapping)
-		// TODO: appoint analyzer
-		nameFieldMapping := bleve.NewKeywordFieldMapping()
-		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
-		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
-		fileIndex, err = bleve.New(*indexPath, indexMapping)
-		if err != nil {
-			return nil, err
-		}
+		return newIndex(*indexPath)
 	} else if err != nil {
 		return nil, err
 	}
+	if !outdated(fileIndex) {
+		return fileIndex, nil
+	}
+	// the mapping of an existing index can't be changed, so the filters on the new fields
+	// would never match the old index, recreate it and ask for rebuilding it
+	log.Warnf("the bleve index lacks the new fields, recreating it, please rebuild the index")
+	if err = fileIndex.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.RemoveAll(*indexPath); err != nil {
+		return nil, err
+	}
+	fileIndex, err = newIndex(*indexPath)
+	if err != nil {
+		return nil, err
+	}
+	resetProgress()
 	return fileIndex, nil
 }
 
+func newIndex(indexPath string) (bleve.Index, error) {
+	indexMapping := bleve.NewIndexMapping()
+	searchNodeMapping := bleve.NewDocumentMapping()
+	searchNodeMapping.AddFieldMappingsAt("is_dir", bleve.NewBooleanFieldMapping())
+	// TODO: appoint analyzer
+	parentFieldMapping := bleve.NewTextFieldMapping()
+	searchNodeMapping.AddFieldMappingsAt("parent", parentFieldMapping)
+	// TODO: appoint analyzer
+	nameFieldMapping := bleve.NewKeywordFieldMapping()
+	searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+	searchNodeMapping.AddFieldMappingsAt("size", bleve.NewNumericFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("modified", bleve.NewDateTimeFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("ext", bleve.NewKeywordFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("type", bleve.NewNumericFieldMapping())
+	hashFieldMapping := bleve.NewTextFieldMapping()
+	hashFieldMapping.Index = false
+	searchNodeMapping.AddFieldMappingsAt("hash", hashFieldMapping)
+	// the content is stored with the term vectors for the highlighted snippets
+	contentFieldMapping := bleve.NewTextFieldMapping()
+	contentFieldMapping.IncludeTermVectors = true
+	searchNodeMapping.AddFieldMappingsAt("content", contentFieldMapping)
+	snippetFieldMapping := bleve.NewTextFieldMapping()
+	snippetFieldMapping.Index, snippetFieldMapping.Store = false, false
+	searchNodeMapping.AddFieldMappingsAt("snippet", snippetFieldMapping)
+	indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
+	return bleve.New(indexPath, indexMapping)
+}
+
+// outdated reports whether the index was created before the fields of the filters were mapped
+func outdated(index bleve.Index) bool {
+	indexMapping, ok := index.Mapping().(*mapping.IndexMappingImpl)
+	if !ok {
+		return false
+	}
+	searchNodeMapping, ok := indexMapping.TypeMapping["SearchNode"]
+	if !ok {
+		return true
+	}
+	_, ok = searchNodeMapping.Properties["type"]
+	return !ok
+}
+
+// resetProgress marks the index as not built, so it is neither updated nor considered done until rebuilt
+func resetProgress() {
+	p, err := utils.Json.MarshalToString(model.IndexProgress{
+		Error: "the index was recreated for the new fields, please rebuild it",
+	})
+	if err != nil {
+		log.Errorf("marshal progress error: %+v", err)
+		return
+	}
+	err = op.SaveSettingItem(&model.SettingItem{
+		Key:   conf.IndexProgress,
+		Value: p,
+		Type:  conf.TypeText,
+		Group: model.SINGLE,
+		Flag:  model.PRIVATE,
+	})
+	if err != nil {
+		log.Errorf("save progress error: %+v", err)
+	}
+}
+
 func init() {
 	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
 		b, err := Init(&conf.Conf.BleveDir)
@@ -3,6 +3,7 @@ package bleve
 import (
 	"context"
 	"os"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/blevesearch/bleve/v2"
 	search2 "github.com/blevesearch/bleve/v2/search"
@@ -27,36 +29,115 @@ func (b *Bleve) Config() searcher.Config {
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	queries = append(queries, keywordsQuery(req.Keywords))
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(req.SearchFilter)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
 	search.SortBy([]string{"name"})
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
-	search.Fields = []string{"*"}
+	search.Fields = []string{"parent", "name", "is_dir", "size", "modified", "hash", "ext", "type"}
+	search.Highlight = bleve.NewHighlightWithStyle("html")
+	search.Highlight.AddField("content")
 	searchResults, err := b.BIndex.Search(search)
 	if err != nil {
 		log.Errorf("search error: %+v", err)
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
+		node := model.SearchNode{
 			Parent: src.Fields["parent"].(string),
 			Name:   src.Fields["name"].(string),
 			IsDir:  src.Fields["is_dir"].(bool),
 			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
+		}
+		// the nodes indexed before have none of the fields below
+		if modified, ok := src.Fields["modified"].(string); ok {
+			node.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+		}
+		node.Hash, _ = src.Fields["hash"].(string)
+		node.Ext, _ = src.Fields["ext"].(string)
+		if objType, ok := src.Fields["type"].(float64); ok {
+			node.ObjType = int(objType)
+		}
+		if fragments := src.Fragments["content"]; len(fragments) > 0 {
+			node.Snippet = fragments[0]
+		}
+		return node, nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
+// keywordsQuery matches the keywords in the name, or in the content if it is indexed
+func keywordsQuery(keywords string) query2.Query {
+	nameQuery := bleve.NewMatchQuery(keywords)
+	nameQuery.SetField("name")
+	if !setting.GetBool(conf.SearchContent) {
+		return nameQuery
+	}
+	contentQuery := bleve.NewMatchQuery(keywords)
+	contentQuery.SetField("content")
+	contentQuery.SetOperator(query2.MatchQueryOperatorAnd)
+	return bleve.NewDisjunctionQuery(nameQuery, contentQuery)
+}
+
+func filterQueries(f model.SearchFilter) []query2.Query {
+	var queries []query2.Query
+	inclusive := true
+	if f.MinSize > 0 || f.MaxSize > 0 {
+		var minSize, maxSize *float64
+		if f.MinSize > 0 {
+			v := float64(f.MinSize)
+			minSize = &v
+		}
+		if f.MaxSize > 0 {
+			v := float64(f.MaxSize)
+			maxSize = &v
+		}
+		q := bleve.NewNumericRangeInclusiveQuery(minSize, maxSize, &inclusive, &inclusive)
+		q.SetField("size")
+		queries = append(queries, q)
+	}
+	if f.ModifiedAfter > 0 || f.ModifiedBefore > 0 {
+		// the zero time means unbounded
+		var start, end time.Time
+		if f.ModifiedAfter > 0 {
+			start = time.Unix(f.ModifiedAfter, 0)
+		}
+		if f.ModifiedBefore > 0 {
+			end = time.Unix(f.ModifiedBefore, 0)
+		}
+		q := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		q.SetField("modified")
+		queries = append(queries, q)
+	}
+	if len(f.Extensions) > 0 {
+		exts := make([]query2.Query, 0, len(f.Extensions))
+		for _, ext := range f.Extensions {
+			q := bleve.NewTermQuery(ext)
+			q.SetField("ext")
+			exts = append(exts, q)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(exts...))
+	}
+	if len(f.Types) > 0 {
+		types := make([]query2.Query, 0, len(f.Types))
+		for _, t := range f.Types {
+			v := float64(t)
+			q := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			q.SetField("type")
+			types = append(types, q)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(types...))
+	}
+	return queries
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
 	return b.BIndex.Index(uuid.NewString(), node)
 }
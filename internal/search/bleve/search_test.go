@@ -0,0 +1,92 @@
+package bleve
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/blevesearch/bleve/v2"
+	query2 "github.com/blevesearch/bleve/v2/search/query"
+)
+
+func TestFilterQueries(t *testing.T) {
+	index, err := newIndex(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	nodes := []model.SearchNode{
+		{Parent: "/", Name: "movie.mp4", Size: 300, Modified: day(1), Ext: "mp4", ObjType: conf.VIDEO},
+		{Parent: "/", Name: "song.mp3", Size: 200, Modified: day(2), Ext: "mp3", ObjType: conf.AUDIO},
+		{Parent: "/", Name: "note.txt", Size: 100, Modified: day(3), Ext: "txt", ObjType: conf.TEXT},
+		{Parent: "/", Name: "photos", IsDir: true, Modified: day(4), ObjType: conf.FOLDER},
+	}
+	b := &Bleve{BIndex: index}
+	if err = b.BatchIndex(context.Background(), nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter model.SearchFilter
+		want   []string
+	}{
+		{"none", model.SearchFilter{}, []string{"movie.mp4", "note.txt", "photos", "song.mp3"}},
+		{"size", model.SearchFilter{MinSize: 150, MaxSize: 250}, []string{"song.mp3"}},
+		{"min size", model.SearchFilter{MinSize: 200}, []string{"movie.mp4", "song.mp3"}},
+		{"modified", model.SearchFilter{ModifiedAfter: day(2).Unix(), ModifiedBefore: day(3).Unix()}, []string{"note.txt", "song.mp3"}},
+		{"extensions", model.SearchFilter{Extensions: []string{"mp4", "txt"}}, []string{"movie.mp4", "note.txt"}},
+		{"types", model.SearchFilter{Types: []int{conf.FOLDER, conf.AUDIO}}, []string{"photos", "song.mp3"}},
+		{"combined", model.SearchFilter{MaxSize: 250, Types: []int{conf.VIDEO, conf.TEXT}}, []string{"note.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := append([]query2.Query{bleve.NewMatchAllQuery()}, filterQueries(tt.filter)...)
+			req := bleve.NewSearchRequest(bleve.NewConjunctionQuery(queries...))
+			req.Fields = []string{"name"}
+			res, err := index.Search(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, hit := range res.Hits {
+				got = append(got, hit.Fields["name"].(string))
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	old, err := bleve.New(filepath.Join(t.TempDir(), "old"), bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	if !outdated(old) {
+		t.Error("the index without the mapping of the new fields should be outdated")
+	}
+	index, err := newIndex(filepath.Join(t.TempDir(), "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	if outdated(index) {
+		t.Error("the new index should not be outdated")
+	}
+}
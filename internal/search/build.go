@@ -19,6 +19,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/mq"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -31,6 +32,31 @@ func Running() bool {
 }
 
 func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth int, count bool) error {
+	var report func(*model.IndexProgress)
+	if count {
+		report = WriteProgress
+	}
+	return BuildIndexWithReport(ctx, indexPaths, ignorePaths, maxDepth, report)
+}
+
+// BuildIndexWithReport is BuildIndex reporting the progress to report instead of the index_progress setting,
+// a nil report disables the progress
+func BuildIndexWithReport(ctx context.Context, indexPaths, ignorePaths []string, maxDepth int, report func(*model.IndexProgress)) error {
+	return buildIndex(ctx, indexPaths, ignorePaths, maxDepth, report, nil)
+}
+
+// RebuildIndex deletes the indexed nodes under indexPath then builds it as BuildIndexWithReport does,
+// the nodes are deleted only after the index is marked running, so a running index is never cleared
+func RebuildIndex(ctx context.Context, indexPath string, ignorePaths []string, maxDepth int, report func(*model.IndexProgress)) error {
+	return buildIndex(ctx, []string{indexPath}, ignorePaths, maxDepth, report, func() error {
+		if indexPath == "/" {
+			return Clear(ctx)
+		}
+		return Del(ctx, indexPath)
+	})
+}
+
+func buildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth int, report func(*model.IndexProgress), deleteOld func() error) error {
 	var (
 		err      error
 		objCount uint64 = 0
@@ -43,6 +69,12 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 		// other goroutine is running
 		return errs.BuildIndexIsRunning
 	}
+	if deleteOld != nil {
+		if err = deleteOld(); err != nil {
+			Quit.CompareAndSwap(&quit, nil)
+			return errors.WithMessage(err, "failed delete old index")
+		}
+	}
 	var (
 		indexMQ = mq.NewInMemoryMQ[ObjWithParent]()
 		running = atomic.Bool{} // current goroutine running
@@ -82,8 +114,8 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 					} else {
 						objCount = objCount + uint64(len(messages))
 					}
-					if count {
-						WriteProgress(&model.IndexProgress{
+					if report != nil {
+						report(&model.IndexProgress{
 							ObjCount:     objCount,
 							IsDone:       false,
 							LastDoneTime: nil,
@@ -111,8 +143,8 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 					} else {
 						log.Infof("success build index, count: %d", objCount)
 					}
-					if count {
-						WriteProgress(&model.IndexProgress{
+					if report != nil {
+						report(&model.IndexProgress{
 							ObjCount:     objCount,
 							IsDone:       true,
 							LastDoneTime: &now,
@@ -141,8 +173,8 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 	if err != nil {
 		return err
 	}
-	if count {
-		WriteProgress(&model.IndexProgress{
+	if report != nil {
+		report(&model.IndexProgress{
 			ObjCount: 0,
 			IsDone:   false,
 		})
@@ -203,9 +235,12 @@ func Update(ctx context.Context, parent string, objs []model.Obj) {
 	if instance == nil || !instance.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) || Running() {
 		return
 	}
-	if isIgnorePath(parent) {
+	if isIgnorePath(parent) || op.IsTrashMountPath(parent) {
 		return
 	}
+	if _, actualPath, err := op.GetStorageAndActualPath(parent); err == nil && utils.PathEqual(actualPath, "/") {
+		objs = op.HideTrashDir(objs)
+	}
 	// only update when index have built
 	progress, err := Progress()
 	if err != nil {
@@ -0,0 +1,58 @@
+// Package content extracts the text of the text and document files for the searchers indexing the content.
+package content
+
+import (
+	"context"
+	"io"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	log "github.com/sirupsen/logrus"
+)
+
+// Enabled reports whether the content should be indexed for the searcher
+func Enabled(cfg searcher.Config) bool {
+	return cfg.Content && setting.GetBool(conf.SearchContent)
+}
+
+// Read returns the text of the file at the path, it is empty if the file is not supported,
+// too large or failed to read, since the file can still be indexed by the name
+func Read(ctx context.Context, path string, obj model.Obj) string {
+	maxSize := int64(setting.GetInt(conf.SearchContentMaxSize, 10)) << 20
+	if obj.IsDir() || obj.GetSize() <= 0 || obj.GetSize() > maxSize || !Supported(obj.GetName()) {
+		return ""
+	}
+	text, err := read(ctx, path, obj.GetName(), obj.GetSize())
+	if err != nil {
+		log.Warnf("failed read content of %s: %+v", path, err)
+		return ""
+	}
+	return text
+}
+
+func read(ctx context.Context, path, name string, size int64) (string, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return "", err
+	}
+	defer link.Close()
+	rr, err := stream.GetRangeReaderFromLink(size, link)
+	if err != nil {
+		return "", err
+	}
+	rc, err := rr.RangeRead(ctx, http_range.Range{Length: size})
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, size))
+	if err != nil {
+		return "", err
+	}
+	return Extract(name, data)
+}
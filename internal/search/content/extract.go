@@ -0,0 +1,242 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// maxContentSize limits the text indexed for a file
+const maxContentSize = 1 << 20
+
+// Supported reports whether the text of the file can be extracted by its name
+func Supported(name string) bool {
+	switch ext := utils.Ext(name); ext {
+	case "pdf", "docx", "odt":
+		return true
+	default:
+		return utils.SliceContains(conf.SlicesMap[conf.TextTypes], ext)
+	}
+}
+
+// Extract returns the plain text of the file with the whitespaces collapsed
+func Extract(name string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+	switch utils.Ext(name) {
+	case "pdf":
+		text = pdfText(data)
+	case "docx":
+		text, err = zipXMLText(data, "word/document.xml")
+	case "odt":
+		text, err = zipXMLText(data, "content.xml")
+	default:
+		text = strings.ToValidUTF8(string(data), "")
+	}
+	if err != nil {
+		return "", err
+	}
+	return normalize(text), nil
+}
+
+func normalize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= maxContentSize {
+		return text
+	}
+	cut := maxContentSize
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+// zipXMLText extracts the character data of the xml file in the zip, paragraphs are separated by spaces
+func zipXMLText(data []byte, name string) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+	var sb strings.Builder
+	d := xml.NewDecoder(io.LimitReader(f, 16*maxContentSize))
+	for sb.Len() < maxContentSize {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.EndElement:
+			// docx w:p and w:tab, odt text:p, text:h and text:tab
+			switch t.Name.Local {
+			case "p", "h", "tab", "br":
+				sb.WriteByte(' ')
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+var (
+	pdfStream    = []byte("stream")
+	pdfEndStream = []byte("endstream")
+)
+
+// pdfText extracts the literal strings shown by the text operators in the content streams.
+// It is not a full parser, the text in the hex strings or encoded with custom fonts is lost.
+func pdfText(data []byte) string {
+	var sb strings.Builder
+	for off := 0; sb.Len() < maxContentSize; {
+		i := bytes.Index(data[off:], pdfStream)
+		if i < 0 {
+			break
+		}
+		start := off + i + len(pdfStream)
+		if off+i >= 3 && bytes.Equal(data[off+i-3:off+i], []byte("end")) {
+			off = start
+			continue
+		}
+		// the keyword stream is followed by CRLF or LF
+		if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+			start += 2
+		} else if bytes.HasPrefix(data[start:], []byte("\n")) {
+			start++
+		} else {
+			off = start
+			continue
+		}
+		j := bytes.Index(data[start:], pdfEndStream)
+		if j < 0 {
+			break
+		}
+		dict := data[max(0, off+i-512) : off+i]
+		if k := bytes.LastIndex(dict, []byte("obj")); k >= 0 {
+			dict = dict[k:]
+		}
+		body := data[start : start+j]
+		off = start + j + len(pdfEndStream)
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			r, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				continue
+			}
+			// a truncated stream still has the text decoded so far
+			body, _ = io.ReadAll(io.LimitReader(r, 16*maxContentSize))
+			_ = r.Close()
+		case bytes.Contains(dict, []byte("/Filter")):
+			continue
+		}
+		pdfContentText(body, &sb)
+	}
+	return sb.String()
+}
+
+// pdfContentText writes the operands of Tj, TJ, ' and " in the content stream
+func pdfContentText(body []byte, sb *strings.Builder) {
+	var operands []string
+	for i := 0; i < len(body); {
+		c := body[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteral(body[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '%':
+			for i < len(body) && body[i] != '\n' && body[i] != '\r' {
+				i++
+			}
+		case c == '/':
+			// skip the name, or its letters would be taken as an operator
+			i++
+			for i < len(body) && !bytes.ContainsRune([]byte(" \t\r\n/[]()<>{}%"), rune(body[i])) {
+				i++
+			}
+		case c == '<' && (i+1 >= len(body) || body[i+1] != '<'):
+			// hex strings are mostly glyph ids of the embedded fonts, skip them
+			for i < len(body) && body[i] != '>' {
+				i++
+			}
+		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '\'' || c == '"':
+			j := i + 1
+			for j < len(body) && (body[j] >= 'A' && body[j] <= 'Z' || body[j] >= 'a' && body[j] <= 'z' || body[j] == '*') {
+				j++
+			}
+			switch string(body[i:j]) {
+			case "Tj", "TJ", "'", "\"":
+				for _, s := range operands {
+					sb.WriteString(s)
+				}
+			case "ET", "Td", "TD", "T*", "Tm":
+				sb.WriteByte(' ')
+			}
+			operands = operands[:0]
+			i = j
+		default:
+			i++
+		}
+	}
+}
+
+var pdfEscapes = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 'b': '\b', 'f': '\f', '(': '(', ')': ')', '\\': '\\'}
+
+// pdfLiteral decodes the literal string starting with "(", it returns the string and the bytes consumed
+func pdfLiteral(b []byte) (string, int) {
+	var sb strings.Builder
+	depth := 0
+	i := 0
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				sb.WriteByte(c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return strings.ToValidUTF8(sb.String(), ""), i + 1
+			}
+			sb.WriteByte(c)
+		case '\\':
+			if i+1 >= len(b) {
+				continue
+			}
+			i++
+			if e, ok := pdfEscapes[b[i]]; ok {
+				sb.WriteByte(e)
+			} else if b[i] >= '0' && b[i] <= '7' {
+				// octal code of up to 3 digits
+				v, n := 0, 0
+				for ; n < 3 && i+n < len(b) && b[i+n] >= '0' && b[i+n] <= '7'; n++ {
+					v = v*8 + int(b[i+n]-'0')
+				}
+				sb.WriteByte(byte(v))
+				i += n - 1
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return strings.ToValidUTF8(sb.String(), ""), i
+}
@@ -0,0 +1,65 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+)
+
+func zipFile(t *testing.T, name, data string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(data))
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDocx(t *testing.T) {
+	data := zipFile(t, "word/document.xml", `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Revenue &amp; costs</w:t></w:r></w:p></w:body></w:document>`)
+	got, err := Extract("a.docx", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Quarterly report Revenue & costs"; got != want {
+		t.Errorf("Extract docx = %q, want %q", got, want)
+	}
+}
+
+func TestExtractPDF(t *testing.T) {
+	var flate bytes.Buffer
+	zw := zlib.NewWriter(&flate)
+	_, _ = zw.Write([]byte("BT /F1 12 Tf 72 712 Td [(Com) -20 (pressed)] TJ ET"))
+	_ = zw.Close()
+	data := fmt.Sprintf("%%PDF-1.4\n4 0 obj\n<< /Length 44 >>\nstream\n"+
+		"BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\)) Tj T* (line\\0412) Tj <0041> Tj ET\nendstream\nendobj\n"+
+		"5 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n"+
+		"6 0 obj\n<< /Filter /DCTDecode >>\nstream\n(Image) Tj\nendstream\nendobj\n", flate.Len(), flate.String())
+	got, err := Extract("a.pdf", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hello (PDF) line!2 Compressed"; got != want {
+		t.Errorf("Extract pdf = %q, want %q", got, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := normalize(" a\n\tb  c "); got != "a b c" {
+		t.Errorf("normalize = %q", got)
+	}
+	long := bytes.Repeat([]byte("é"), maxContentSize)
+	if got := normalize(string(long)); len(got) != maxContentSize {
+		t.Errorf("normalize long = %d bytes, want %d", len(got), maxContentSize)
+	}
+}
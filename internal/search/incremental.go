@@ -0,0 +1,240 @@
+package search
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const incrementalBatchSize = 1000
+
+var errIndexStopped = errors.New("index stopped")
+
+// nodeChanged reports whether the indexed file differs from the object in the storage,
+// the modified time is compared in seconds since not every searcher keeps the nanoseconds
+func nodeChanged(node *model.SearchNode, obj model.Obj) bool {
+	if node.Size != obj.GetSize() || node.Modified.Unix() != obj.ModTime().Unix() {
+		return true
+	}
+	if node.Hash != "" {
+		if h := model.NewSearchNode("", obj).Hash; h != "" {
+			return h != node.Hash
+		}
+	}
+	return false
+}
+
+type incrementalIndexer struct {
+	ctx         context.Context
+	quit        chan struct{}
+	ignorePaths []string
+	report      func(*model.IndexProgress)
+	batch       []ObjWithParent
+	objCount    uint64
+}
+
+func (u *incrementalIndexer) stopped() bool {
+	select {
+	case <-u.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (u *incrementalIndexer) ignored(p string) bool {
+	for _, avoidPath := range u.ignorePaths {
+		if strings.HasPrefix(p, avoidPath) {
+			return true
+		}
+	}
+	if storage, _, err := op.GetStorageAndActualPath(p); err == nil && storage.GetStorage().DisableIndex {
+		return true
+	}
+	return false
+}
+
+func (u *incrementalIndexer) add(parent string, obj model.Obj) error {
+	u.batch = append(u.batch, ObjWithParent{Parent: parent, Obj: obj})
+	if len(u.batch) < incrementalBatchSize {
+		return nil
+	}
+	return u.flush()
+}
+
+func (u *incrementalIndexer) flush() error {
+	if len(u.batch) == 0 {
+		return nil
+	}
+	if err := BatchIndex(u.ctx, u.batch); err != nil {
+		return err
+	}
+	u.objCount += uint64(len(u.batch))
+	u.batch = u.batch[:0]
+	if u.report != nil {
+		u.report(&model.IndexProgress{ObjCount: u.objCount})
+	}
+	return nil
+}
+
+// addTree indexes the new object and everything under it
+func (u *incrementalIndexer) addTree(reqPath string, obj model.Obj, depth int) error {
+	return fs.WalkFS(u.ctx, depth, reqPath, obj, func(p string, info model.Obj) error {
+		if u.stopped() {
+			return errIndexStopped
+		}
+		if u.ignored(p) {
+			return filepath.SkipDir
+		}
+		return u.add(path.Dir(p), info)
+	})
+}
+
+// walk diffs the listing of the dir with the indexed nodes, a dir failed to list is skipped
+// rather than taken as empty, otherwise all of its nodes would be deleted
+func (u *incrementalIndexer) walk(dir string, depth int) error {
+	if depth == 0 {
+		return nil
+	}
+	if u.stopped() {
+		return errIndexStopped
+	}
+	meta, _ := op.GetNearestMeta(dir)
+	objs, err := fs.List(context.WithValue(u.ctx, conf.MetaKey, meta), dir, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		log.Warnf("incremental index skips %s: %+v", dir, err)
+		return nil
+	}
+	nodes, err := instance.Get(u.ctx, dir)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get indexed nodes of %s", dir)
+	}
+	indexed := make(map[string]*model.SearchNode, len(nodes))
+	for i := range nodes {
+		indexed[nodes[i].Name] = &nodes[i]
+	}
+	var dirs []string
+	for _, obj := range objs {
+		p := path.Join(dir, obj.GetName())
+		node, ok := indexed[obj.GetName()]
+		delete(indexed, obj.GetName())
+		if u.ignored(p) {
+			continue
+		}
+		switch {
+		case ok && node.IsDir && obj.IsDir():
+			dirs = append(dirs, p)
+			continue
+		case ok && !node.IsDir && !obj.IsDir() && !nodeChanged(node, obj):
+			continue
+		case ok:
+			log.Debugf("reindex: %s", p)
+			if err = instance.Del(u.ctx, p); err != nil {
+				return err
+			}
+		default:
+			log.Debugf("add index: %s", p)
+		}
+		if err = u.addTree(p, obj, depth-1); err != nil {
+			return err
+		}
+	}
+	for name := range indexed {
+		p := path.Join(dir, name)
+		if op.HasStorage(p) {
+			continue
+		}
+		log.Debugf("delete index: %s", p)
+		if err = instance.Del(u.ctx, p); err != nil {
+			return err
+		}
+	}
+	for _, p := range dirs {
+		if err = u.walk(p, depth-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRoot indexes the index path itself if it is missing, as BuildIndex does
+func (u *incrementalIndexer) addRoot(indexPath string) error {
+	if indexPath == "/" {
+		return nil
+	}
+	root, err := fs.Get(u.ctx, indexPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return err
+	}
+	nodes, err := instance.Get(u.ctx, path.Dir(indexPath))
+	if err != nil {
+		return err
+	}
+	for i := range nodes {
+		if nodes[i].Name == root.GetName() {
+			return nil
+		}
+	}
+	return u.add(path.Dir(indexPath), root)
+}
+
+// IncrementalIndex compares the listing of each folder under indexPath with the indexed nodes,
+// then only indexes the new or changed objects and deletes the removed ones.
+// It requires a searcher supporting Get, and shares the running state of BuildIndex.
+func IncrementalIndex(ctx context.Context, indexPath string, ignorePaths []string, maxDepth int, report func(*model.IndexProgress)) error {
+	if instance == nil || !instance.Config().AutoUpdate {
+		return errors.New("incremental index is not supported for current index")
+	}
+	quit := make(chan struct{}, 1)
+	if !Quit.CompareAndSwap(nil, &quit) {
+		return errs.BuildIndexIsRunning
+	}
+	defer Quit.CompareAndSwap(&quit, nil)
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return err
+	}
+	log.Infof("incremental index for: %s", indexPath)
+	u := &incrementalIndexer{
+		ctx:         context.WithValue(ctx, conf.UserKey, admin),
+		quit:        quit,
+		ignorePaths: ignorePaths,
+		report:      report,
+	}
+	if report != nil {
+		report(&model.IndexProgress{})
+	}
+	err = u.addRoot(indexPath)
+	if err == nil {
+		err = u.walk(indexPath, maxDepth)
+	}
+	if errors.Is(err, errIndexStopped) {
+		log.Debugf("incremental index for %s stopped by StopIndex", indexPath)
+		err = nil
+	}
+	if e := u.flush(); err == nil {
+		err = e
+	}
+	now := time.Now()
+	progress := &model.IndexProgress{ObjCount: u.objCount, IsDone: true, LastDoneTime: &now}
+	if err != nil {
+		log.Errorf("incremental index error: %+v", err)
+		progress.Error = err.Error()
+	} else {
+		log.Infof("success incremental index, count: %d", u.objCount)
+	}
+	if report != nil {
+		report(progress)
+	}
+	return err
+}
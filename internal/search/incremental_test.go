@@ -0,0 +1,196 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+// memSearcher keeps the nodes in memory and counts the indexed nodes
+type memSearcher struct {
+	mu      sync.Mutex
+	nodes   map[string]model.SearchNode
+	indexed int
+	deleted []string
+}
+
+func (m *memSearcher) Config() searcher.Config {
+	return searcher.Config{Name: "memory", AutoUpdate: true}
+}
+
+func (m *memSearcher) Search(context.Context, model.SearchReq) ([]model.SearchNode, int64, error) {
+	return nil, 0, errs.NotImplement
+}
+
+func (m *memSearcher) Index(ctx context.Context, node model.SearchNode) error {
+	return m.BatchIndex(ctx, []model.SearchNode{node})
+}
+
+func (m *memSearcher) BatchIndex(_ context.Context, nodes []model.SearchNode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, node := range nodes {
+		m.nodes[path.Join(node.Parent, node.Name)] = node
+	}
+	m.indexed += len(nodes)
+	return nil
+}
+
+func (m *memSearcher) Get(_ context.Context, parent string) ([]model.SearchNode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var nodes []model.SearchNode
+	for _, node := range m.nodes {
+		if node.Parent == parent {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func (m *memSearcher) Del(_ context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, prefix)
+	for p := range m.nodes {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			delete(m.nodes, p)
+		}
+	}
+	return nil
+}
+
+func (m *memSearcher) Release(context.Context) error {
+	return nil
+}
+
+func (m *memSearcher) Clear(context.Context) error {
+	return m.Del(context.Background(), "")
+}
+
+func (m *memSearcher) paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var paths []string
+	for p, node := range m.nodes {
+		paths = append(paths, p+":"+strconv.FormatInt(node.Size, 10))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func useMemSearcher(t *testing.T) *memSearcher {
+	m := &memSearcher{nodes: make(map[string]model.SearchNode)}
+	old := instance
+	instance = m
+	t.Cleanup(func() { instance = old })
+	return m
+}
+
+func writeFile(t *testing.T, name, content string) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIncrementalIndex(t *testing.T) {
+	if _, err := op.GetAdmin(); err != nil {
+		if err = op.CreateUser(&model.User{Username: "admin", Role: model.ADMIN, Permission: 0x71FF}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "sub", "b.txt"), "b")
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/incremental",
+		Addition:  `{"root_folder_path":` + strconv.Quote(root) + `}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := useMemSearcher(t)
+	index := func() {
+		t.Helper()
+		if err := IncrementalIndex(context.Background(), "/incremental", nil, -1, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index()
+	want := []string{"/incremental/a.txt:1", "/incremental/sub/b.txt:1", "/incremental/sub:0", "/incremental:0"}
+	if got := m.paths(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("indexed %q, want %q", got, want)
+	}
+
+	// nothing changed, nothing is indexed again
+	m.indexed = 0
+	index()
+	if m.indexed != 0 {
+		t.Errorf("reindexed %d unchanged nodes", m.indexed)
+	}
+
+	if err = os.Remove(filepath.Join(root, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "sub", "b.txt"), "bb")
+	writeFile(t, filepath.Join(root, "c.txt"), "ccc")
+	op.Cache.ClearAll()
+	index()
+	want = []string{"/incremental/c.txt:3", "/incremental/sub/b.txt:2", "/incremental/sub:0", "/incremental:0"}
+	if got := m.paths(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("indexed %q, want %q", got, want)
+	}
+	if m.indexed != 2 {
+		t.Errorf("indexed %d nodes, want the changed and the new file", m.indexed)
+	}
+}
+
+func TestRebuildIndexRunning(t *testing.T) {
+	m := useMemSearcher(t)
+	quit := make(chan struct{}, 1)
+	if !Quit.CompareAndSwap(nil, &quit) {
+		t.Fatal("index is running")
+	}
+	defer Quit.Store(nil)
+	if err := RebuildIndex(context.Background(), "/", nil, -1, nil); !errors.Is(err, errs.BuildIndexIsRunning) {
+		t.Errorf("RebuildIndex = %v, want BuildIndexIsRunning", err)
+	}
+	if err := IncrementalIndex(context.Background(), "/", nil, -1, nil); !errors.Is(err, errs.BuildIndexIsRunning) {
+		t.Errorf("IncrementalIndex = %v, want BuildIndexIsRunning", err)
+	}
+	// the running index is not cleared
+	if len(m.deleted) != 0 {
+		t.Errorf("deleted %q while the index is running", m.deleted)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/content"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/meilisearch/meilisearch-go"
@@ -25,6 +26,8 @@ type searchDocument struct {
 	// Can be used for filtering all descendants exactly.
 	// Storing path hashes instead of plaintext paths benefits disk usage and case-sensitive filter.
 	ParentPathHashes []string `json:"parent_path_hashes"`
+	// Unix time of the modified time, since only numbers can be filtered by range
+	ModifiedAt int64 `json:"modified_at"`
 	model.SearchNode
 }
 
@@ -42,10 +45,18 @@ func (m *Meilisearch) Config() searcher.Config {
 
 func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	mReq := &meilisearch.SearchRequest{
-		AttributesToSearchOn: m.SearchableAttributes,
+		AttributesToSearchOn: []string{"name"},
 		Page:                 int64(req.Page),
 		HitsPerPage:          int64(req.PerPage),
 	}
+	if content.Enabled(config) {
+		mReq.AttributesToSearchOn = m.SearchableAttributes
+		mReq.AttributesToCrop = []string{"content"}
+		mReq.CropLength = snippetWords
+		mReq.AttributesToHighlight = []string{"content"}
+		mReq.HighlightPreTag, mReq.HighlightPostTag = highlightPreTag, highlightPostTag
+		mReq.ShowMatchesPosition = true
+	}
 	var filters []string
 	if req.Scope != 0 {
 		filters = append(filters, fmt.Sprintf("is_dir = %v", req.Scope == 1))
@@ -55,6 +66,7 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		parentHash := hashPath(req.Parent)
 		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", parentHash))
 	}
+	filters = append(filters, buildFilters(req.SearchFilter)...)
 	if len(filters) > 0 {
 		mReq.Filter = strings.Join(filters, " AND ")
 	}
@@ -64,13 +76,10 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		hit := src.(map[string]any)
+		node := buildSearchDocumentFromResults(hit).SearchNode
+		node.Snippet = contentSnippet(hit)
+		return node, nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -99,6 +108,7 @@ func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode)
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedAt:       src.Modified.Unix(),
 			SearchNode:       src,
 		}, nil
 	})
@@ -261,6 +271,7 @@ func (m *Meilisearch) batchIndexWithTaskUID(ctx context.Context, nodes []model.S
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedAt:       src.Modified.Unix(),
 			SearchNode:       src,
 		}, nil
 	})
@@ -11,6 +11,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/content"
 	mapset "github.com/deckarep/golang-set/v2"
 	log "github.com/sirupsen/logrus"
 )
@@ -232,15 +233,16 @@ func (tqm *TaskQueueManager) executeTask(ctx context.Context, task *QueuedTask)
 
 	// Collect objects to add
 	var nodesToAdd []model.SearchNode
+	withContent := content.Enabled(config)
 	for i := range currentObjs {
 		if toAdd.Contains(currentObjs[i].GetName()) {
-			log.Debugf("will add index: %s", path.Join(parent, currentObjs[i].GetName()))
-			nodesToAdd = append(nodesToAdd, model.SearchNode{
-				Parent: parent,
-				Name:   currentObjs[i].GetName(),
-				IsDir:  currentObjs[i].IsDir(),
-				Size:   currentObjs[i].GetSize(),
-			})
+			nodePath := path.Join(parent, currentObjs[i].GetName())
+			log.Debugf("will add index: %s", nodePath)
+			node := model.NewSearchNode(parent, currentObjs[i])
+			if withContent {
+				node.Content = content.Read(ctx, nodePath, currentObjs[i])
+			}
+			nodesToAdd = append(nodesToAdd, node)
 		}
 	}
 
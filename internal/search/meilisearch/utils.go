@@ -1,6 +1,12 @@
 package meilisearch
 
 import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
@@ -22,9 +28,79 @@ func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
 	if size, ok := results["size"].(float64); ok {
 		document.SearchNode.Size = int64(size)
 	}
+	if modified, ok := results["modified"].(string); ok {
+		document.SearchNode.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+	}
+	document.SearchNode.Hash, _ = results["hash"].(string)
+	document.SearchNode.Ext, _ = results["ext"].(string)
+	if objType, ok := results["type"].(float64); ok {
+		document.SearchNode.ObjType = int(objType)
+	}
+	if modifiedAt, ok := results["modified_at"].(float64); ok {
+		document.ModifiedAt = int64(modifiedAt)
+	}
 
 	document.ID, _ = results["id"].(string)
 	document.ParentHash, _ = results["parent_hash"].(string)
 	document.ParentPathHashes, _ = results["parent_path_hashes"].([]string)
 	return document
 }
+
+// snippetWords is the number of words around the matches in the snippet
+const snippetWords = 24
+
+// the highlight tags are control characters replaced with <mark> after escaping the snippet,
+// so the snippet is html like the one of bleve
+const (
+	highlightPreTag  = "\x02"
+	highlightPostTag = "\x03"
+)
+
+// contentSnippet returns the cropped and highlighted content of the hit if the content matches the keywords,
+// meilisearch crops the beginning of the content even if only the name matches
+func contentSnippet(hit map[string]any) string {
+	matches, _ := hit["_matchesPosition"].(map[string]any)
+	if _, ok := matches["content"]; !ok {
+		return ""
+	}
+	formatted, _ := hit["_formatted"].(map[string]any)
+	snippet, _ := formatted["content"].(string)
+	return strings.NewReplacer(highlightPreTag, "<mark>", highlightPostTag, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// quoteFilter quotes the string value in the filter expression
+func quoteFilter(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "\\'") + "'"
+}
+
+// buildFilters converts the search filter into the filter expressions of meilisearch
+func buildFilters(f model.SearchFilter) []string {
+	var filters []string
+	if f.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", f.MinSize))
+	}
+	if f.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", f.MaxSize))
+	}
+	if f.ModifiedAfter > 0 {
+		filters = append(filters, fmt.Sprintf("modified_at >= %d", f.ModifiedAfter))
+	}
+	if f.ModifiedBefore > 0 {
+		filters = append(filters, fmt.Sprintf("modified_at <= %d", f.ModifiedBefore))
+	}
+	if len(f.Extensions) > 0 {
+		exts := make([]string, 0, len(f.Extensions))
+		for _, ext := range f.Extensions {
+			exts = append(exts, quoteFilter(ext))
+		}
+		filters = append(filters, fmt.Sprintf("ext IN [%s]", strings.Join(exts, ", ")))
+	}
+	if len(f.Types) > 0 {
+		types := make([]string, 0, len(f.Types))
+		for _, t := range f.Types {
+			types = append(types, fmt.Sprint(t))
+		}
+		filters = append(filters, fmt.Sprintf("type IN [%s]", strings.Join(types, ", ")))
+	}
+	return filters
+}
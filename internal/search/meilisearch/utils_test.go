@@ -0,0 +1,30 @@
+package meilisearch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestBuildFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter model.SearchFilter
+		want   []string
+	}{
+		{"none", model.SearchFilter{}, nil},
+		{"size", model.SearchFilter{MinSize: 1, MaxSize: 10}, []string{"size >= 1", "size <= 10"}},
+		{"modified", model.SearchFilter{ModifiedAfter: 100, ModifiedBefore: 200}, []string{"modified_at >= 100", "modified_at <= 200"}},
+		{"extensions", model.SearchFilter{Extensions: []string{"mp4", "it's"}}, []string{`ext IN ['mp4', 'it\'s']`}},
+		{"types", model.SearchFilter{Types: []int{conf.FOLDER, conf.VIDEO}}, []string{"type IN [1, 2]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildFilters(tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package search
 import (
 	"context"
 	"fmt"
+	"path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/content"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 	log "github.com/sirupsen/logrus"
 )
@@ -55,12 +57,16 @@ func Index(ctx context.Context, parent string, obj model.Obj) error {
 	if instance == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
-	})
+	return instance.Index(ctx, newSearchNode(ctx, parent, obj, content.Enabled(instance.Config())))
+}
+
+// newSearchNode reads the content of the file as well if withContent
+func newSearchNode(ctx context.Context, parent string, obj model.Obj, withContent bool) model.SearchNode {
+	node := model.NewSearchNode(parent, obj)
+	if withContent {
+		node.Content = content.Read(ctx, path.Join(parent, obj.GetName()), obj)
+	}
+	return node
 }
 
 type ObjWithParent struct {
@@ -76,13 +82,9 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 		return nil
 	}
 	var searchNodes []model.SearchNode
+	withContent := content.Enabled(instance.Config())
 	for i := range objs {
-		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
-		})
+		searchNodes = append(searchNodes, newSearchNode(ctx, objs[i].Parent, objs[i].Obj, withContent))
 	}
 	return instance.BatchIndex(ctx, searchNodes)
 }
@@ -9,6 +9,8 @@ import (
 type Config struct {
 	Name       string
 	AutoUpdate bool
+	// Content means the searcher can index and search the text of the files
+	Content bool
 }
 
 type Searcher interface {
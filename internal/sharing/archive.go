@@ -18,10 +18,13 @@ func archiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Valid() {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
+	if sharing.Hidden(path) {
+		return sharing, nil, errors.WithStack(errs.SharingHidden)
+	}
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
@@ -45,10 +48,13 @@ func archiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Valid() {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
+	if sharing.Hidden(path) {
+		return sharing, nil, errors.WithStack(errs.SharingHidden)
+	}
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
@@ -0,0 +1,131 @@
+package sharing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+type speedLimiter struct {
+	speed   int
+	limiter stream.Limiter
+}
+
+var (
+	limitersMu sync.Mutex
+	// limiters are the download limiters of the sharings by id, shared by all downloads of a sharing
+	limiters = make(map[string]*speedLimiter)
+	// reserved are the bytes of the byte caps reserved by the running downloads of the sharings by id
+	reserved = make(map[string]*atomic.Int64)
+	// disabling are the ids of the sharings being disabled by the byte caps
+	disabling sync.Map
+)
+
+// DownloadLimiter returns the limiter of the download speed of the sharing, or nil if it is unlimited
+func DownloadLimiter(s *model.Sharing) stream.Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if s.SpeedLimit <= 0 {
+		delete(limiters, s.ID)
+		return nil
+	}
+	l, ok := limiters[s.ID]
+	if !ok || l.speed != s.SpeedLimit {
+		l = &speedLimiter{
+			speed:   s.SpeedLimit,
+			limiter: stream.NewLimiter(rate.Limit(s.SpeedLimit)*1024, s.SpeedLimit*1024, "share_download"),
+		}
+		limiters[s.ID] = l
+	}
+	return l.limiter
+}
+
+// DeleteLimits drops the limiters of the deleted sharing
+func DeleteLimits(sid string) {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	delete(limiters, sid)
+	delete(reserved, sid)
+}
+
+// ByteReservation reserves the bytes of the byte cap of a sharing for a download, so the parallel
+// downloads can't exceed the cap together. The bytes are counted by AddBytesServed after the download,
+// then the reservation is released.
+type ByteReservation struct {
+	s        *model.Sharing
+	reserved *atomic.Int64
+	n        int64
+}
+
+func NewByteReservation(s *model.Sharing) *ByteReservation {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	r, ok := reserved[s.ID]
+	if !ok {
+		r = &atomic.Int64{}
+		reserved[s.ID] = r
+	}
+	return &ByteReservation{s: s, reserved: r}
+}
+
+// Reserve reserves up to n bytes left by the served and reserved bytes, it returns the bytes reserved
+func (b *ByteReservation) Reserve(n int64) int64 {
+	for {
+		cur := b.reserved.Load()
+		left := b.s.MaxBytes - atomic.LoadInt64(&b.s.BytesServed) - cur
+		if left <= 0 {
+			return 0
+		}
+		n = min(n, left)
+		if b.reserved.CompareAndSwap(cur, cur+n) {
+			b.n += n
+			return n
+		}
+	}
+}
+
+// Unreserve gives back the bytes reserved but not written
+func (b *ByteReservation) Unreserve(n int64) {
+	b.reserved.Add(-n)
+	b.n -= n
+}
+
+// Release gives back all bytes reserved, they must have been counted by AddBytesServed
+func (b *ByteReservation) Release() {
+	b.Unreserve(b.n)
+}
+
+// AddBytesServed counts the downloaded bytes of the sharing, the sharing is disabled
+// and the creator is notified by webhooks once its byte cap is reached
+func AddBytesServed(s *model.Sharing, n int64) {
+	if n <= 0 {
+		return
+	}
+	if err := op.AddSharingBytesServed(s, n); err != nil {
+		log.Errorf("failed add served bytes of sharing [%s]: %+v", s.ID, err)
+		return
+	}
+	if !s.BytesExceeded() || s.Disabled {
+		return
+	}
+	// the downloads finished at the same time disable it once
+	if _, loaded := disabling.LoadOrStore(s.ID, struct{}{}); loaded {
+		return
+	}
+	defer disabling.Delete(s.ID)
+	if err := op.DisableSharing(s.ID); err != nil {
+		log.Errorf("failed disable sharing [%s]: %+v", s.ID, err)
+		return
+	}
+	log.Infof("sharing [%s] is disabled as %d bytes are served over the cap %d", s.ID, atomic.LoadInt64(&s.BytesServed), s.MaxBytes)
+	ctx := context.WithValue(context.Background(), conf.UserKey, s.Creator)
+	webhook.EmitShare(ctx, webhook.EventShareBytesExceeded, s.ID, s.Files, "")
+}
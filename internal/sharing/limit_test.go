@@ -0,0 +1,50 @@
+package sharing
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestByteReservation(t *testing.T) {
+	s := &model.Sharing{SharingDB: &model.SharingDB{ID: "reserve", MaxBytes: 1000, BytesServed: 100}}
+	defer DeleteLimits(s.ID)
+	// the parallel downloads share the bytes left by the cap
+	var (
+		wg    sync.WaitGroup
+		total atomic.Int64
+	)
+	rs := make([]*ByteReservation, 10)
+	for i := range rs {
+		rs[i] = NewByteReservation(s)
+		wg.Add(1)
+		go func(r *ByteReservation) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				total.Add(r.Reserve(10))
+			}
+		}(rs[i])
+	}
+	wg.Wait()
+	if n := total.Load(); n != 900 {
+		t.Errorf("reserved %d bytes, want the 900 bytes left", n)
+	}
+	r := NewByteReservation(s)
+	if n := r.Reserve(1); n != 0 {
+		t.Errorf("reserved %d bytes over the cap", n)
+	}
+	// the bytes not written are given back
+	rs[0].Unreserve(50)
+	if n := r.Reserve(100); n != 50 {
+		t.Errorf("reserved %d bytes, want the 50 bytes given back", n)
+	}
+	for _, r := range rs {
+		r.Release()
+	}
+	r.Release()
+	if n := NewByteReservation(s).Reserve(1000); n != 900 {
+		t.Errorf("reserved %d bytes after the releases, want 900", n)
+	}
+}
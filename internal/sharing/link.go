@@ -20,10 +20,13 @@ func link(ctx context.Context, sid, path string, args *LinkArgs) (*model.Sharing
 	if !sharing.Valid() {
 		return sharing, nil, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, nil, errors.WithStack(errs.WrongShareCode)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
+	if sharing.Hidden(path) {
+		return sharing, nil, nil, errors.WithStack(errs.SharingHidden)
+	}
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
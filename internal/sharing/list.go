@@ -19,8 +19,11 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 	if !sharing.Valid() {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
+	}
+	if sharing.HideFiles {
+		return sharing, []model.Obj{}, nil
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
@@ -42,6 +45,9 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 			if err != nil && len(virtualFiles) == 0 {
 				return nil, nil, errors.WithMessage(err, "failed list sharing")
 			}
+			if utils.PathEqual(actualPath, "/") {
+				objs = op.HideTrashDir(objs)
+			}
 		}
 		om := model.NewObjMerge()
 		objs = om.Merge(objs, virtualFiles...)
@@ -68,7 +74,7 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 			continue
 		}
 		storage, actualPath, err := op.GetStorageAndActualPath(f)
-		if err != nil {
+		if err != nil || op.IsTrashPath(actualPath) {
 			continue
 		}
 		obj, err := op.Get(ctx, storage, actualPath)
@@ -0,0 +1,68 @@
+package sharing
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxFileStats limits the files in the stats to the most downloaded ones
+const maxFileStats = 100
+
+// RecordAccess saves the visit of a sharing if the access log is enabled
+func RecordAccess(a *model.ShareAccess) {
+	if !setting.GetBool(conf.ShareAccessLogEnabled) {
+		return
+	}
+	a.Bytes = max(a.Bytes, 0)
+	if err := db.CreateShareAccess(a); err != nil {
+		log.Errorf("failed save access of sharing [%s]: %+v", a.SharingID, err)
+	}
+}
+
+// Stats aggregates the visits of the sharing in the last days
+func Stats(s *model.Sharing, days int) (*model.ShareStats, error) {
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-days)
+	accesses, err := db.GetShareAccessesSince(s.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	files, err := db.GetShareFileStats(s.ID, since, maxFileStats)
+	if err != nil {
+		return nil, err
+	}
+	stats := aggregate(accesses)
+	stats.Accessed = s.Accessed
+	stats.Files = files
+	return stats, nil
+}
+
+// aggregate counts the visits and the unique ips in total and per day of the accesses sorted by time
+func aggregate(accesses []model.ShareAccess) *model.ShareStats {
+	stats := &model.ShareStats{Files: []model.ShareFileStat{}, Daily: []model.ShareDailyStat{}}
+	ips := make(map[string]struct{})
+	var dayIPs map[string]struct{}
+	for _, a := range accesses {
+		date := a.CreatedAt.Local().Format(time.DateOnly)
+		if n := len(stats.Daily); n == 0 || stats.Daily[n-1].Date != date {
+			stats.Daily = append(stats.Daily, model.ShareDailyStat{Date: date})
+			dayIPs = make(map[string]struct{})
+		}
+		day := &stats.Daily[len(stats.Daily)-1]
+		day.Visits++
+		if _, ok := dayIPs[a.IP]; !ok {
+			dayIPs[a.IP] = struct{}{}
+			day.UniqueVisitors++
+		}
+		ips[a.IP] = struct{}{}
+		stats.Visits++
+		stats.Bytes += a.Bytes
+	}
+	stats.UniqueVisitors = len(ips)
+	return stats
+}
@@ -0,0 +1,33 @@
+package sharing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestAggregate(t *testing.T) {
+	day := time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local)
+	stats := aggregate([]model.ShareAccess{
+		{CreatedAt: day, IP: "1.1.1.1", Bytes: 10},
+		{CreatedAt: day.Add(time.Hour), IP: "1.1.1.1"},
+		{CreatedAt: day.Add(2 * time.Hour), IP: "2.2.2.2", Bytes: 5},
+		{CreatedAt: day.AddDate(0, 0, 2), IP: "1.1.1.1"},
+	})
+	if stats.Visits != 4 || stats.UniqueVisitors != 2 || stats.Bytes != 15 {
+		t.Errorf("stats = %+v", stats)
+	}
+	want := []model.ShareDailyStat{
+		{Date: "2025-03-01", Visits: 3, UniqueVisitors: 2},
+		{Date: "2025-03-03", Visits: 1, UniqueVisitors: 1},
+	}
+	if len(stats.Daily) != len(want) {
+		t.Fatalf("daily = %+v", stats.Daily)
+	}
+	for i := range want {
+		if stats.Daily[i] != want[i] {
+			t.Errorf("daily[%d] = %+v, want %+v", i, stats.Daily[i], want[i])
+		}
+	}
+}
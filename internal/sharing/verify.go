@@ -0,0 +1,42 @@
+package sharing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+)
+
+// attempts counts the wrong share codes by sharing and ip
+var attempts = cache.NewMemCache[int]()
+
+// Verify checks the share code sent from the ip, the ip is locked out of the sharing for
+// model.DefaultLockDuration after model.DefaultMaxAuthRetries wrong codes. An empty code is not
+// counted as an attempt, as the clients try without the code first to know whether it is required.
+func Verify(s *model.Sharing, pwd, ip string) error {
+	key := fmt.Sprintf("%s:%s", s.ID, ip)
+	count, _ := attempts.Get(key)
+	if count >= model.DefaultMaxAuthRetries {
+		attempts.Expire(key, model.DefaultLockDuration)
+		return errors.WithStack(errs.SharingLocked)
+	}
+	if s.Verify(pwd) {
+		if count > 0 {
+			attempts.Del(key)
+		}
+		return nil
+	}
+	if pwd != "" {
+		attempts.Set(key, count+1, cache.WithEx[int](model.DefaultLockDuration))
+	}
+	return errors.WithStack(errs.WrongShareCode)
+}
+
+func verify(ctx context.Context, s *model.Sharing, pwd string) error {
+	ip, _ := ctx.Value(conf.ClientIPKey).(string)
+	return Verify(s, pwd, ip)
+}
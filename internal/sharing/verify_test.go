@@ -0,0 +1,43 @@
+package sharing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestVerifyLockout(t *testing.T) {
+	s := &model.Sharing{SharingDB: (&model.SharingDB{ID: "lockout"}).SetPwd("code")}
+	if s.Pwd != "" || s.PwdHash == "" {
+		t.Fatalf("share code is not hashed: %+v", s.SharingDB)
+	}
+	if err := Verify(s, "code", "1.1.1.1"); err != nil {
+		t.Fatalf("verify right code: %v", err)
+	}
+	if err := Verify(s, "", "1.1.1.1"); !errors.Is(err, errs.WrongShareCode) {
+		t.Fatalf("verify empty code: %v", err)
+	}
+	for i := 0; i < model.DefaultMaxAuthRetries; i++ {
+		if err := Verify(s, "wrong", "1.1.1.1"); !errors.Is(err, errs.WrongShareCode) {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	if err := Verify(s, "code", "1.1.1.1"); !errors.Is(err, errs.SharingLocked) {
+		t.Errorf("verify locked ip: %v", err)
+	}
+	if err := Verify(s, "code", "2.2.2.2"); err != nil {
+		t.Errorf("verify other ip: %v", err)
+	}
+}
+
+func TestVerifyPlainPwd(t *testing.T) {
+	s := &model.Sharing{SharingDB: &model.SharingDB{ID: "plain", Pwd: "code"}}
+	if !s.HasPwd() {
+		t.Fatal("the sharing with a plain share code should require a share code")
+	}
+	if err := Verify(s, "code", "1.1.1.1"); !errors.Is(err, errs.WrongShareCode) {
+		t.Errorf("the plain share code should not be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package sign
+
+import (
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+)
+
+var onceArchiveDownload sync.Once
+var instanceArchiveDownload sign.Sign
+
+// SignArchiveDownload signs the path and the query of a /z link,
+// links of archive downloads never live longer than a day
+func SignArchiveDownload(data string) string {
+	expire := setting.GetInt(conf.LinkExpiration, 0)
+	if expire == 0 || expire > 24 {
+		expire = 24
+	}
+	onceArchiveDownload.Do(InstanceArchiveDownload)
+	return instanceArchiveDownload.Sign(data, time.Now().Add(time.Duration(expire)*time.Hour).Unix())
+}
+
+func VerifyArchiveDownload(data string, sign string) error {
+	onceArchiveDownload.Do(InstanceArchiveDownload)
+	return instanceArchiveDownload.Verify(data, sign)
+}
+
+func InstanceArchiveDownload() {
+	instanceArchiveDownload = sign.NewHMACSign([]byte(setting.GetStr(conf.Token) + "-archive-download"))
+}
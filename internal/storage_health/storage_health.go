@@ -0,0 +1,256 @@
+// Package storage_health lists the root of each storage periodically. A storage failing the checks
+// a number of times in a row is initialized again if enabled, e.g. to refresh an expired token,
+// and is marked degraded if it still fails, then it is retried with backoff until it passes again.
+package storage_health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	tickInterval = time.Minute
+	historySize  = 50
+	checkTimeout = time.Minute
+	minBackoff   = time.Minute
+	maxBackoff   = time.Hour
+	// checkWorkers is the max number of storages checked at the same time
+	checkWorkers = 8
+)
+
+type Check struct {
+	Time time.Time `json:"time"`
+	// Latency of the check in milliseconds, including the reload
+	Latency  int64  `json:"latency"`
+	Error    string `json:"error,omitempty"`
+	Reloaded bool   `json:"reloaded"`
+}
+
+type Health struct {
+	StorageID uint      `json:"storage_id"`
+	MountPath string    `json:"mount_path"`
+	Driver    string    `json:"driver"`
+	Status    string    `json:"status"`
+	Degraded  bool      `json:"degraded"`
+	Failures  int       `json:"failures"`
+	LastCheck time.Time `json:"last_check"`
+	NextCheck time.Time `json:"next_check"`
+	LastError string    `json:"last_error"`
+	History   []Check   `json:"history"`
+}
+
+var (
+	mu     sync.Mutex
+	states = make(map[uint]*Health)
+)
+
+// Init checks the storages due every minute
+func Init() {
+	cron.NewCron(tickInterval).Do(tick)
+}
+
+func tick() {
+	interval := time.Duration(setting.GetInt(conf.StorageHealthInterval, 5)) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	opts := checkOptions{
+		interval:  interval,
+		threshold: max(setting.GetInt(conf.StorageHealthThreshold, 3), 1),
+		reload:    setting.GetBool(conf.StorageHealthReload),
+	}
+	// the ticks may come a bit earlier than the next checks scheduled a tick ago
+	now := time.Now().Add(tickInterval / 2)
+	alive := make(map[uint]bool)
+	var due []driver.Driver
+	for _, storage := range op.GetAllStorages() {
+		id := storage.GetStorage().ID
+		alive[id] = true
+		mu.Lock()
+		h, ok := states[id]
+		if !ok || !now.Before(h.NextCheck) {
+			due = append(due, storage)
+		}
+		mu.Unlock()
+	}
+	// a slow storage only holds up one worker, the tick waits for all the checks,
+	// so a storage is never checked twice at the same time
+	forEach(due, checkWorkers, func(storage driver.Driver) {
+		check(storage, opts)
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	for id := range states {
+		if !alive[id] {
+			delete(states, id)
+		}
+	}
+}
+
+// forEach calls fn for the items with at most workers calls at the same time, and returns after all of them
+func forEach[T any](items []T, workers int, fn func(T)) {
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(item)
+		}()
+	}
+	wg.Wait()
+}
+
+type checkOptions struct {
+	interval time.Duration
+	// threshold is the number of consecutive failures before the storage is reloaded or marked degraded
+	threshold int
+	reload    bool
+}
+
+func check(storage driver.Driver, opts checkOptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	c := Check{Time: time.Now()}
+	err := listRoot(ctx, storage)
+	if err != nil && opts.reload && failures(storage.GetStorage().ID)+1 >= opts.threshold {
+		c.Reloaded = true
+		if err = reload(ctx, storage); err == nil {
+			err = listRoot(ctx, storage)
+		}
+	}
+	c.Latency = time.Since(c.Time).Milliseconds()
+	record(storage, c, err, opts)
+}
+
+func failures(id uint) int {
+	mu.Lock()
+	defer mu.Unlock()
+	if h, ok := states[id]; ok {
+		return h.Failures
+	}
+	return 0
+}
+
+// listRoot recovers the panics of the drivers failed to initialize
+func listRoot(ctx context.Context, storage driver.Driver) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	_, err = op.List(ctx, storage, "/", model.ListArgs{Refresh: true, SkipHook: true})
+	return err
+}
+
+func reload(ctx context.Context, storage driver.Driver) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	return op.ReloadStorage(ctx, storage)
+}
+
+// backoff doubles the delay of the next check from minBackoff up to maxBackoff
+func backoff(failures int) time.Duration {
+	return min(minBackoff<<min(failures-1, 6), maxBackoff)
+}
+
+// update records the check in the health, a failure below the threshold is only counted and checked again
+// in minBackoff. It reports whether the storage becomes degraded or recovered by the check.
+func (h *Health) update(c Check, opts checkOptions) (degraded, recovered bool) {
+	wasDegraded := h.Degraded
+	h.LastCheck = c.Time
+	if c.Error != "" {
+		h.Failures++
+		h.LastError = c.Error
+		h.Degraded = h.Failures >= opts.threshold
+		h.NextCheck = c.Time.Add(backoff(max(h.Failures-opts.threshold+1, 1)))
+	} else {
+		h.Degraded = false
+		h.Failures = 0
+		h.NextCheck = c.Time.Add(opts.interval)
+	}
+	h.History = append(h.History, c)
+	if len(h.History) > historySize {
+		h.History = h.History[len(h.History)-historySize:]
+	}
+	return h.Degraded && !wasDegraded, wasDegraded && !h.Degraded
+}
+
+func record(storage driver.Driver, c Check, err error, opts checkOptions) {
+	s := storage.GetStorage()
+	if err != nil {
+		c.Error = err.Error()
+	}
+	mu.Lock()
+	h, ok := states[s.ID]
+	if !ok {
+		h = &Health{StorageID: s.ID}
+		states[s.ID] = h
+	}
+	h.MountPath, h.Driver = s.MountPath, s.Driver
+	degraded, recovered := h.update(c, opts)
+	isDegraded, failures := h.Degraded, h.Failures
+	mu.Unlock()
+
+	if isDegraded {
+		s.SetStatus(fmt.Sprintf("%s: %s", op.DEGRADED, c.Error))
+		op.MustSaveDriverStorage(storage)
+	} else if err == nil && s.Status != op.WORK {
+		s.SetStatus(op.WORK)
+		op.MustSaveDriverStorage(storage)
+	}
+	metrics.SetStorageUp(s.MountPath, !isDegraded)
+
+	switch {
+	case degraded:
+		log.Warnf("storage [%s] is degraded: %s", s.MountPath, c.Error)
+		webhook.EmitStorage(context.Background(), webhook.EventStorageDegraded, s.MountPath, s.Driver, c.Error)
+	case err != nil:
+		log.Debugf("storage [%s] failed health check %d times: %s", s.MountPath, failures, c.Error)
+	case recovered:
+		log.Infof("storage [%s] is recovered", s.MountPath)
+		webhook.EmitStorage(context.Background(), webhook.EventStorageRecovered, s.MountPath, s.Driver, "")
+	}
+}
+
+// List returns the health of the checked storages sorted by mount path
+func List() []Health {
+	mu.Lock()
+	res := make([]Health, 0, len(states))
+	for _, h := range states {
+		cp := *h
+		cp.History = append([]Check(nil), h.History...)
+		res = append(res, cp)
+	}
+	mu.Unlock()
+	for i := range res {
+		if storage, err := op.GetStorageByMountPath(res[i].MountPath); err == nil {
+			res[i].Status = storage.GetStorage().Status
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].MountPath < res[j].MountPath
+	})
+	return res
+}
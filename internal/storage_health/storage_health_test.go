@@ -0,0 +1,67 @@
+package storage_health
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthUpdate(t *testing.T) {
+	opts := checkOptions{interval: 5 * time.Minute, threshold: 3}
+	h := &Health{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fail := Check{Time: now, Error: "timeout"}
+	for i := 1; i < opts.threshold; i++ {
+		if degraded, _ := h.update(fail, opts); degraded || h.Degraded {
+			t.Fatalf("degraded after %d failures", i)
+		}
+		if h.NextCheck != now.Add(minBackoff) {
+			t.Errorf("next check in %v after %d failures, want %v", h.NextCheck.Sub(now), i, minBackoff)
+		}
+	}
+	if degraded, _ := h.update(fail, opts); !degraded || !h.Degraded {
+		t.Fatal("not degraded after the threshold")
+	}
+	if degraded, _ := h.update(fail, opts); degraded {
+		t.Error("degraded is reported twice")
+	}
+	if h.NextCheck != now.Add(2*minBackoff) {
+		t.Errorf("next check in %v, want the backoff %v", h.NextCheck.Sub(now), 2*minBackoff)
+	}
+	if _, recovered := h.update(Check{Time: now}, opts); !recovered || h.Degraded || h.Failures != 0 {
+		t.Errorf("not recovered: %+v", h)
+	}
+	if h.NextCheck != now.Add(opts.interval) {
+		t.Errorf("next check in %v, want the interval", h.NextCheck.Sub(now))
+	}
+	// a success resets the consecutive failures
+	h.update(fail, opts)
+	h.update(Check{Time: now}, opts)
+	h.update(fail, opts)
+	if h.Degraded || h.Failures != 1 {
+		t.Errorf("failures = %d, degraded = %v", h.Failures, h.Degraded)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	var running, maxRunning, done atomic.Int32
+	items := make([]int, 20)
+	forEach(items, 3, func(int) {
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		done.Add(1)
+	})
+	if done.Load() != 20 {
+		t.Errorf("done %d, want 20", done.Load())
+	}
+	if maxRunning.Load() > 3 {
+		t.Errorf("%d checks at the same time, want at most 3", maxRunning.Load())
+	}
+}
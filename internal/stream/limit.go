@@ -5,6 +5,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"golang.org/x/time/rate"
@@ -34,6 +35,36 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+type blockBurstLimiter struct {
+	*rate.Limiter
+	name string
+}
+
+// NewLimiter returns a limiter of which WaitN waits for more tokens than the burst in several rounds,
+// the waits are observed in the metrics by the name
+func NewLimiter(limit rate.Limit, burst int, name string) Limiter {
+	return blockBurstLimiter{Limiter: rate.NewLimiter(limit, burst), name: name}
+}
+
+func (l blockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	if l.Limiter.Limit() != rate.Inf {
+		start := time.Now()
+		defer func() { metrics.ObserveRateLimitWait(l.name, time.Since(start)) }()
+	}
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
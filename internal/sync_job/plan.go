@@ -0,0 +1,181 @@
+package sync_job
+
+import (
+	"context"
+	stdpath "path"
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	ActionCopy   = "copy"
+	ActionRemove = "remove"
+
+	ReasonMissing     = "missing"
+	ReasonChanged     = "changed"
+	ReasonExtraneous  = "extraneous"
+	ReasonTypeChanged = "type_changed"
+)
+
+// modTimeWindow tolerates the modified time precision of the storages
+const modTimeWindow = 2 * time.Second
+
+type Action struct {
+	Action string `json:"action"`
+	// Path is the object to copy or remove
+	Path   string `json:"path"`
+	DstDir string `json:"dst_dir,omitempty"`
+	IsDir  bool   `json:"is_dir"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+type Plan struct {
+	Actions []Action `json:"actions"`
+}
+
+func (p *Plan) add(action string, path, dstDir string, obj model.Obj, reason string) {
+	p.Actions = append(p.Actions, Action{
+		Action: action,
+		Path:   path,
+		DstDir: dstDir,
+		IsDir:  obj.IsDir(),
+		Size:   obj.GetSize(),
+		Reason: reason,
+	})
+}
+
+// hashEqual compares the hashes of the same kind, ok is false if there is no such hash
+func hashEqual(a, b model.Obj) (equal, ok bool) {
+	for ht, ah := range a.GetHash().All() {
+		if bh := b.GetHash().GetHash(ht); ah != "" && bh != "" {
+			return ah == bh, true
+		}
+	}
+	return false, false
+}
+
+// contentDiffers reports whether the content of the files differs by the hashes or the sizes
+func contentDiffers(a, b model.Obj) bool {
+	if equal, ok := hashEqual(a, b); ok {
+		return !equal
+	}
+	return a.GetSize() != b.GetSize()
+}
+
+// changed reports whether src should overwrite dst, it is also true if src is modified later than dst,
+// unless the hashes tell they are the same
+func changed(src, dst model.Obj) bool {
+	if equal, ok := hashEqual(src, dst); ok {
+		return !equal
+	}
+	return src.GetSize() != dst.GetSize() || src.ModTime().After(dst.ModTime().Add(modTimeWindow))
+}
+
+type planner struct {
+	ctx  context.Context
+	job  *model.SyncJob
+	plan *Plan
+}
+
+// list returns the objects in the dir by name, a missing dir is regarded as empty
+func (p *planner) list(path string) (map[string]model.Obj, []string, error) {
+	objs, err := fs.List(p.ctx, path, &fs.ListArgs{Refresh: true, NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.WithMessagef(err, "failed list %s", path)
+	}
+	m := make(map[string]model.Obj, len(objs))
+	names := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		m[obj.GetName()] = obj
+		names = append(names, obj.GetName())
+	}
+	slices.Sort(names)
+	return m, names, nil
+}
+
+func (p *planner) walk(srcDir, dstDir string) error {
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+	srcObjs, srcNames, err := p.list(srcDir)
+	if err != nil {
+		return err
+	}
+	dstObjs, dstNames, err := p.list(dstDir)
+	if err != nil {
+		return err
+	}
+	mode := p.job.Mode
+	for _, name := range srcNames {
+		src, srcPath := srcObjs[name], stdpath.Join(srcDir, name)
+		dst, exists := dstObjs[name]
+		dstPath := stdpath.Join(dstDir, name)
+		switch {
+		case !exists:
+			p.plan.add(ActionCopy, srcPath, dstDir, src, ReasonMissing)
+		case src.IsDir() && dst.IsDir():
+			if err = p.walk(srcPath, dstPath); err != nil {
+				return err
+			}
+		case src.IsDir() != dst.IsDir():
+			// a file replaced by a folder or the opposite, only mirror is allowed to remove the old one
+			if mode == model.SyncMirror && p.job.DeleteExtraneous {
+				p.plan.add(ActionRemove, dstPath, "", dst, ReasonTypeChanged)
+				p.plan.add(ActionCopy, srcPath, dstDir, src, ReasonTypeChanged)
+			}
+		case mode == model.SyncMirror:
+			if changed(src, dst) {
+				p.plan.add(ActionCopy, srcPath, dstDir, src, ReasonChanged)
+			}
+		case mode == model.SyncBidirectional:
+			// a copy may not keep the modified time, so only the content decides whether to copy,
+			// otherwise the files would be copied back and forth in every run
+			if contentDiffers(src, dst) {
+				if dst.ModTime().After(src.ModTime()) {
+					p.plan.add(ActionCopy, dstPath, srcDir, dst, ReasonChanged)
+				} else {
+					p.plan.add(ActionCopy, srcPath, dstDir, src, ReasonChanged)
+				}
+			}
+		}
+	}
+	for _, name := range dstNames {
+		if _, ok := srcObjs[name]; ok {
+			continue
+		}
+		dst, dstPath := dstObjs[name], stdpath.Join(dstDir, name)
+		switch {
+		case mode == model.SyncMirror && p.job.DeleteExtraneous:
+			p.plan.add(ActionRemove, dstPath, "", dst, ReasonExtraneous)
+		case mode == model.SyncBidirectional:
+			p.plan.add(ActionCopy, dstPath, srcDir, dst, ReasonMissing)
+		}
+	}
+	return nil
+}
+
+// MakePlan diffs the listings of the source and destination of the job without changing anything
+func MakePlan(ctx context.Context, job *model.SyncJob) (*Plan, error) {
+	// never take a missing source as empty, it would remove everything in the destination
+	src, err := fs.Get(ctx, job.SrcPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get source")
+	}
+	if !src.IsDir() {
+		return nil, errors.Errorf("source %s is not a folder", job.SrcPath)
+	}
+	p := &planner{ctx: ctx, job: job, plan: &Plan{Actions: []Action{}}}
+	if err = p.walk(job.SrcPath, job.DstPath); err != nil {
+		return nil, err
+	}
+	return p.plan, nil
+}
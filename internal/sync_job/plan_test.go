@@ -0,0 +1,42 @@
+package sync_job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestChanged(t *testing.T) {
+	now := time.Now()
+	obj := func(size int64, modified time.Time, md5 string) *model.Object {
+		o := &model.Object{Size: size, Modified: modified}
+		if md5 != "" {
+			o.HashInfo = utils.NewHashInfo(utils.MD5, md5)
+		}
+		return o
+	}
+	tests := []struct {
+		name     string
+		src, dst *model.Object
+		changed  bool
+		differs  bool
+	}{
+		{"same", obj(1, now, ""), obj(1, now, ""), false, false},
+		{"size", obj(1, now, ""), obj(2, now, ""), true, true},
+		{"src newer", obj(1, now.Add(time.Minute), ""), obj(1, now, ""), true, false},
+		{"within window", obj(1, now.Add(time.Second), ""), obj(1, now, ""), false, false},
+		{"dst newer", obj(1, now, ""), obj(1, now.Add(time.Minute), ""), false, false},
+		{"hash equal", obj(1, now.Add(time.Minute), "aa"), obj(1, now, "aa"), false, false},
+		{"hash differs", obj(1, now, "aa"), obj(1, now, "bb"), true, true},
+	}
+	for _, tt := range tests {
+		if got := changed(tt.src, tt.dst); got != tt.changed {
+			t.Errorf("%s: changed = %v, want %v", tt.name, got, tt.changed)
+		}
+		if got := contentDiffers(tt.src, tt.dst); got != tt.differs {
+			t.Errorf("%s: contentDiffers = %v, want %v", tt.name, got, tt.differs)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package sync_job keeps the folders of two mount paths in sync by copying the changed objects,
+// either on a cron schedule or manually.
+package sync_job
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Validate checks the mode, the cron expression and the paths of the job
+func Validate(j *model.SyncJob) error {
+	switch j.Mode {
+	case "":
+		j.Mode = model.SyncMirror
+	case model.SyncMirror, model.SyncCopyNew, model.SyncBidirectional:
+	default:
+		return errors.Errorf("unknown sync mode: %s", j.Mode)
+	}
+	// the other modes keep no previous state to tell the extraneous objects from the new ones
+	if j.DeleteExtraneous && j.Mode != model.SyncMirror {
+		return errors.Errorf("deleting extraneous objects is not supported by the %s mode", j.Mode)
+	}
+	j.Cron = strings.TrimSpace(j.Cron)
+	if j.Cron != "" {
+		if _, err := cron.ParseSchedule(j.Cron); err != nil {
+			return err
+		}
+	}
+	j.SrcPath, j.DstPath = utils.FixAndCleanPath(j.SrcPath), utils.FixAndCleanPath(j.DstPath)
+	if utils.IsSubPath(j.SrcPath, j.DstPath) || utils.IsSubPath(j.DstPath, j.SrcPath) {
+		return errors.New("the source and destination must not contain each other")
+	}
+	return nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	j.ID = 0
+	j.LastRunAt, j.LastResult = time.Time{}, ""
+	return db.CreateSyncJob(j)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	old, err := db.GetSyncJobById(j.ID)
+	if err != nil {
+		return err
+	}
+	j.LastRunAt, j.LastResult = old.LastRunAt, old.LastResult
+	return db.UpdateSyncJob(j)
+}
+
+var running sync.Map
+
+func jobContext() (context.Context, error) {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(context.Background(), conf.UserKey, admin), nil
+}
+
+// Start runs the job in background, it fails if the job is already running
+func Start(j *model.SyncJob) error {
+	if _, loaded := running.LoadOrStore(j.ID, struct{}{}); loaded {
+		return errors.Errorf("sync job [%s] is already running", j.Name)
+	}
+	ctx, err := jobContext()
+	if err != nil {
+		running.Delete(j.ID)
+		return err
+	}
+	go func() {
+		defer running.Delete(j.ID)
+		run(ctx, j)
+	}()
+	return nil
+}
+
+func run(ctx context.Context, j *model.SyncJob) {
+	j.LastRunAt = time.Now()
+	plan, err := MakePlan(ctx, j)
+	if err != nil {
+		j.LastResult = "failed plan: " + err.Error()
+	} else {
+		j.LastResult = execute(ctx, j, plan)
+	}
+	log.Infof("sync job [%s]: %s", j.Name, j.LastResult)
+	if err = db.UpdateSyncJobResult(j); err != nil {
+		log.Errorf("failed save result of sync job [%s]: %+v", j.Name, err)
+	}
+}
+
+// execute removes the objects first, since a removed object may be replaced by a copied one,
+// then copies the objects. The copies run in the job rather than as copy tasks, so a copy is only
+// counted once it succeeded, and the job keeps running until all of them are done.
+func execute(ctx context.Context, j *model.SyncJob, plan *Plan) string {
+	if err := fs.MakeDir(ctx, j.DstPath); err != nil {
+		return "failed make destination: " + err.Error()
+	}
+	var copied, removed, failed int
+	for _, a := range plan.Actions {
+		if a.Action != ActionRemove {
+			continue
+		}
+		if err := fs.Remove(ctx, a.Path); err != nil {
+			failed++
+		} else {
+			removed++
+		}
+	}
+	copyCtx := context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	for _, a := range plan.Actions {
+		if a.Action != ActionCopy {
+			continue
+		}
+		if _, err := fs.Copy(copyCtx, a.Path, a.DstDir); err != nil {
+			log.Warnf("sync job [%s] failed copy %s to %s: %+v", j.Name, a.Path, a.DstDir, err)
+			failed++
+		} else {
+			copied++
+		}
+	}
+	return fmt.Sprintf("%d copied, %d removed, %d failed", copied, removed, failed)
+}
+
+var lastTick time.Time
+
+// tick starts the jobs scheduled between the last tick and now
+func tick() {
+	now := time.Now()
+	defer func() { lastTick = now }()
+	jobs, err := db.GetScheduledSyncJobs()
+	if err != nil {
+		log.Errorf("failed get scheduled sync jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		j := &jobs[i]
+		s, err := cron.ParseSchedule(j.Cron)
+		if err != nil {
+			log.Warnf("invalid cron of sync job [%s]: %v", j.Name, err)
+			continue
+		}
+		if next := s.Next(lastTick); next.IsZero() || next.After(now) {
+			continue
+		}
+		if err = Start(j); err != nil {
+			log.Warnf("skip scheduled sync job: %v", err)
+		}
+	}
+}
+
+// Init checks the scheduled jobs every minute
+func Init() {
+	lastTick = time.Now()
+	cron.NewCron(time.Minute).Do(tick)
+}
@@ -0,0 +1,27 @@
+package sync_job
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		job  model.SyncJob
+		ok   bool
+	}{
+		{"mirror", model.SyncJob{SrcPath: "/a", DstPath: "/b", DeleteExtraneous: true}, true},
+		{"bidirectional", model.SyncJob{SrcPath: "/a", DstPath: "/b", Mode: model.SyncBidirectional}, true},
+		{"bidirectional delete", model.SyncJob{SrcPath: "/a", DstPath: "/b", Mode: model.SyncBidirectional, DeleteExtraneous: true}, false},
+		{"copy new delete", model.SyncJob{SrcPath: "/a", DstPath: "/b", Mode: model.SyncCopyNew, DeleteExtraneous: true}, false},
+		{"unknown mode", model.SyncJob{SrcPath: "/a", DstPath: "/b", Mode: "unknown"}, false},
+		{"nested", model.SyncJob{SrcPath: "/a", DstPath: "/a/b"}, false},
+	}
+	for _, tt := range tests {
+		if err := Validate(&tt.job); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
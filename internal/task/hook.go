@@ -0,0 +1,16 @@
+package task
+
+// StateHook is called when a task finally succeeds or fails, i.e. no more retry will happen
+type StateHook = func(t TaskExtensionInfo, succeeded bool)
+
+var stateHooks = make([]StateHook, 0)
+
+func RegisterStateHook(hook StateHook) {
+	stateHooks = append(stateHooks, hook)
+}
+
+func HandleStateHook(t TaskExtensionInfo, succeeded bool) {
+	for _, hook := range stateHooks {
+		hook(t, succeeded)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -67,6 +69,15 @@ func HookAndRemove(ctx context.Context, dstPath string, payloads ...any) {
 			err = verifyAndRemove(ctx, srcStorage, dstStorage, srcActualPath, dstActualPath)
 			if err != nil {
 				log.Error(err)
+				continue
+			}
+			dstObjPath := path.Join(dstPath, path.Base(string(p)))
+			if err = db.MoveWebdavProps(string(p), dstObjPath); err != nil {
+				log.Errorf("failed move webdav props of %s to %s: %+v", string(p), dstObjPath, err)
+			}
+			// the trash emits the removals itself
+			if ctx.Value(conf.TrashAccessKey) == nil {
+				webhook.EmitFs(ctx, webhook.EventMove, string(p), dstObjPath)
 			}
 		}
 	}
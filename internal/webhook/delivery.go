@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/avast/retry-go"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderEvent     = "X-OpenList-Event"
+	HeaderDelivery  = "X-OpenList-Delivery"
+	HeaderSignature = "X-OpenList-Signature"
+
+	signatureExpire = 10 * time.Minute
+	maxRetryDelay   = 10 * time.Minute
+)
+
+var (
+	clientOnce sync.Once
+	client     *http.Client
+)
+
+func httpClient() *http.Client {
+	clientOnce.Do(func() {
+		client = net.NewHttpClient()
+		client.Timeout = 30 * time.Second
+	})
+	return client
+}
+
+func newDelivery(w *model.Webhook, p *Payload) (*model.WebhookDelivery, []byte, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	d := &model.WebhookDelivery{
+		WebhookID: w.ID,
+		Event:     p.Event,
+		Payload:   string(body),
+	}
+	if err = db.CreateWebhookDelivery(d); err != nil {
+		return nil, nil, err
+	}
+	return d, body, nil
+}
+
+// deliver posts the body to the webhook, retrying with exponential backoff up to w.MaxRetry times
+func deliver(w model.Webhook, d *model.WebhookDelivery, body []byte) {
+	err := retry.Do(func() error {
+		d.Attempts++
+		code, err := post(&w, d, body)
+		d.StatusCode = code
+		if err != nil && code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests {
+			return retry.Unrecoverable(err)
+		}
+		return err
+	},
+		retry.LastErrorOnly(true),
+		retry.Attempts(uint(max(w.MaxRetry, 0)+1)),
+		retry.Delay(time.Second),
+		retry.MaxDelay(maxRetryDelay),
+		retry.DelayType(retry.BackOffDelay))
+	d.Success = err == nil
+	if err != nil {
+		d.Error = err.Error()
+		log.Warnf("failed deliver [%s] to webhook [%d] after %d attempts: %s", d.Event, w.ID, d.Attempts, err)
+	}
+	if err = db.UpdateWebhookDelivery(d); err != nil {
+		log.Errorf("failed update webhook delivery: %+v", err)
+	}
+}
+
+func post(w *model.Webhook, d *model.WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OpenList-Webhook")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(d.ID), 10))
+	if w.Secret != "" {
+		req.Header.Set(HeaderSignature, sign.NewHMACSign([]byte(w.Secret)).Sign(string(body), time.Now().Add(signatureExpire).Unix()))
+	}
+	res, err := httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return res.StatusCode, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return res.StatusCode, fmt.Errorf("unexpected status %s: %s", res.Status, msg)
+}
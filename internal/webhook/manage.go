@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/errors"
+)
+
+// Validate checks the url, events and path globs of the webhook
+func Validate(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid webhook url: %s", w.URL)
+	}
+	for _, e := range splitEvents(w.Events) {
+		if !utils.SliceContains(Events, e) {
+			return errors.Errorf("unknown event: %s", e)
+		}
+	}
+	for _, p := range splitPaths(w.Paths) {
+		if !doublestar.ValidatePattern(p) {
+			return errors.Errorf("invalid path glob: %s", p)
+		}
+	}
+	if w.MaxRetry < 0 {
+		w.MaxRetry = 0
+	}
+	return nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	w.ID = 0
+	if err := db.CreateWebhook(w); err != nil {
+		return err
+	}
+	invalidate()
+	return nil
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	if _, err := db.GetWebhookById(w.ID); err != nil {
+		return err
+	}
+	if err := db.UpdateWebhook(w); err != nil {
+		return err
+	}
+	invalidate()
+	return nil
+}
+
+func DeleteWebhookById(id uint) error {
+	if err := db.DeleteWebhookById(id); err != nil {
+		return err
+	}
+	invalidate()
+	return nil
+}
+
+// Ping sends a ping event to the webhook synchronously without retry, disabled webhooks can be tested too
+func Ping(id uint) (*model.WebhookDelivery, error) {
+	w, err := db.GetWebhookById(id)
+	if err != nil {
+		return nil, err
+	}
+	d, body, err := newDelivery(w, &Payload{
+		Event: EventPing,
+		Time:  time.Now(),
+		Data:  map[string]any{"webhook_id": w.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.Attempts = 1
+	code, err := post(w, d, body)
+	d.StatusCode, d.Success = code, err == nil
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if e := db.UpdateWebhookDelivery(d); e != nil {
+		return nil, e
+	}
+	return d, nil
+}
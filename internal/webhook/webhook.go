@@ -0,0 +1,222 @@
+// Package webhook delivers filesystem, sharing and task events to the configured http endpoints.
+//
+// The payload is posted as json. If the webhook has a secret, the X-OpenList-Signature header
+// carries an HMAC-SHA256 signature of the body in the format of pkg/sign, i.e. "<sign>:<expire>",
+// so the receiver can check it with sign.NewHMACSign(secret).Verify(body, signature).
+package webhook
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/bmatcuk/doublestar/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	EventUpload        = "fs.upload"
+	EventRemove        = "fs.remove"
+	EventRename        = "fs.rename"
+	EventMove          = "fs.move"
+	EventShareCreate   = "share.create"
+	EventShareAccess   = "share.access"
+	EventTaskSucceeded = "task.succeeded"
+	EventTaskFailed    = "task.failed"
+	// EventStorageDegraded is sent when a storage fails the health check and can't be reinitialized,
+	// EventStorageRecovered when it passes the health check again
+	EventStorageDegraded  = "storage.degraded"
+	EventStorageRecovered = "storage.recovered"
+	// EventShareBytesExceeded is sent when a sharing is disabled as its byte cap is reached,
+	// the username of the payload is the creator of the sharing
+	EventShareBytesExceeded = "share.bytes_exceeded"
+	// EventPing is only sent when testing a webhook
+	EventPing = "ping"
+)
+
+var Events = []string{
+	EventUpload, EventRemove, EventRename, EventMove,
+	EventShareCreate, EventShareAccess,
+	EventTaskSucceeded, EventTaskFailed,
+	EventStorageDegraded, EventStorageRecovered,
+	EventShareBytesExceeded,
+}
+
+type Payload struct {
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	Username string    `json:"username,omitempty"`
+	Data     any       `json:"data,omitempty"`
+}
+
+type FsData struct {
+	Path     string `json:"path"`
+	DstPath  string `json:"dst_path,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+type ShareData struct {
+	ID    string   `json:"id"`
+	Files []string `json:"files"`
+	IP    string   `json:"ip,omitempty"`
+}
+
+type TaskData struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Creator string `json:"creator,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type StorageData struct {
+	MountPath string `json:"mount_path"`
+	Driver    string `json:"driver"`
+	Error     string `json:"error,omitempty"`
+}
+
+var (
+	mu       sync.RWMutex
+	webhooks []model.Webhook
+	loaded   bool
+)
+
+func getEnabledWebhooks() []model.Webhook {
+	mu.RLock()
+	if loaded {
+		defer mu.RUnlock()
+		return webhooks
+	}
+	mu.RUnlock()
+	mu.Lock()
+	defer mu.Unlock()
+	if !loaded {
+		res, err := db.GetEnabledWebhooks()
+		if err != nil {
+			log.Errorf("failed load webhooks: %+v", err)
+			return nil
+		}
+		webhooks, loaded = res, true
+	}
+	return webhooks
+}
+
+func invalidate() {
+	mu.Lock()
+	defer mu.Unlock()
+	webhooks, loaded = nil, false
+}
+
+func match(w *model.Webhook, event string, paths []string) bool {
+	if w.Events != "" && !utils.SliceContains(splitEvents(w.Events), event) {
+		return false
+	}
+	if w.Paths == "" || len(paths) == 0 {
+		return true
+	}
+	for _, pattern := range splitPaths(w.Paths) {
+		for _, p := range paths {
+			if ok, _ := doublestar.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitEvents(events string) []string {
+	var res []string
+	for _, e := range strings.Split(events, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func splitPaths(paths string) []string {
+	var res []string
+	for _, p := range strings.Split(paths, "\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Emit sends the event to all enabled webhooks subscribing it,
+// paths are the paths involved in the event and are matched against the path globs of the webhooks
+func Emit(ctx context.Context, event string, data any, paths ...string) {
+	var matched []model.Webhook
+	for _, w := range getEnabledWebhooks() {
+		if match(&w, event, paths) {
+			matched = append(matched, w)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+	p := Payload{
+		Event: event,
+		Time:  time.Now(),
+		Data:  data,
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		p.Username = user.Username
+	}
+	for _, w := range matched {
+		d, body, err := newDelivery(&w, &p)
+		if err != nil {
+			log.Errorf("failed create delivery of webhook [%d]: %+v", w.ID, err)
+			continue
+		}
+		go deliver(w, d, body)
+	}
+}
+
+func EmitFs(ctx context.Context, event, path, dstPath string) {
+	protocol, _ := ctx.Value(conf.ProtocolKey).(string)
+	data := FsData{Path: path, DstPath: dstPath, Protocol: protocol}
+	if dstPath == "" {
+		Emit(ctx, event, data, path)
+	} else {
+		Emit(ctx, event, data, path, dstPath)
+	}
+}
+
+func EmitShare(ctx context.Context, event, id string, files []string, ip string) {
+	Emit(ctx, event, ShareData{ID: id, Files: files, IP: ip}, files...)
+}
+
+func EmitStorage(ctx context.Context, event, mountPath, driver, errMsg string) {
+	Emit(ctx, event, StorageData{MountPath: mountPath, Driver: driver, Error: errMsg}, mountPath)
+}
+
+func handleTaskState(t task.TaskExtensionInfo, succeeded bool) {
+	data := TaskData{
+		ID:   t.GetID(),
+		Name: t.GetName(),
+	}
+	ctx := context.Background()
+	if creator := t.GetCreator(); creator != nil {
+		data.Creator = creator.Username
+		ctx = context.WithValue(ctx, conf.UserKey, creator)
+	}
+	event := EventTaskSucceeded
+	if !succeeded {
+		event = EventTaskFailed
+		if err := t.GetErr(); err != nil {
+			data.Error = err.Error()
+		}
+	}
+	Emit(ctx, event, data)
+}
+
+func init() {
+	task.RegisterStateHook(handleTaskState)
+}
@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		w      model.Webhook
+		event  string
+		paths  []string
+		expect bool
+	}{
+		{"all", model.Webhook{}, EventUpload, []string{"/a/b.txt"}, true},
+		{"event", model.Webhook{Events: "fs.remove, fs.upload"}, EventUpload, nil, true},
+		{"other event", model.Webhook{Events: "fs.remove"}, EventUpload, nil, false},
+		{"glob", model.Webhook{Paths: "/x/**\n/a/*.txt"}, EventUpload, []string{"/a/b.txt"}, true},
+		{"glob not match", model.Webhook{Paths: "/a/*.txt"}, EventUpload, []string{"/a/c/b.txt"}, false},
+		{"dst path", model.Webhook{Paths: "/dst/**"}, EventMove, []string{"/src/a", "/dst/a"}, true},
+		{"no path", model.Webhook{Paths: "/a/**"}, EventTaskFailed, nil, true},
+	}
+	for _, tt := range tests {
+		if got := match(&tt.w, tt.event, tt.paths); got != tt.expect {
+			t.Errorf("%s: expect %v, got %v", tt.name, tt.expect, got)
+		}
+	}
+}
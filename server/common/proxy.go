@@ -10,6 +10,7 @@ import (
 	"maps"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/net"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
@@ -63,11 +64,12 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 	if r.Method == http.MethodHead {
 		return nil
 	}
-	_, err = utils.CopyWithBuffer(w, &stream.RateLimitReader{
+	written, err := utils.CopyWithBuffer(w, &stream.RateLimitReader{
 		Reader:  res.Body,
 		Limiter: stream.ServerDownloadLimit,
 		Ctx:     r.Context(),
 	})
+	metrics.AddTransferredBytes("proxy", written)
 	return err
 }
 func attachHeader(w http.ResponseWriter, file model.Obj, link *model.Link) {
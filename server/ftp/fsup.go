@@ -133,7 +133,7 @@ func (f *FileUploadProxy) Close() error {
 	task, err := fs.PutAsTask(f.ctx, dir, s)
 	if err != nil {
 		_ = s.Close()
-		return err
+		return quotaErr(err)
 	}
 	sf.SetRemoveCallback(func() {
 		fs.UploadTaskManager.Cancel(task.GetID())
@@ -239,7 +239,7 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			return err
 		}
 		err = <-f.errChan
-		return err
+		return quotaErr(err)
 	} else {
 		data := f.first512Bytes[:f.pFirst]
 		contentType := http.DetectContentType(data)
@@ -254,6 +254,14 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			WebPutAsTask: false,
 			Reader:       bytes.NewReader(data),
 		}
-		return fs.PutDirectly(f.ctx, dir, s)
+		return quotaErr(fs.PutDirectly(f.ctx, dir, s))
+	}
+}
+
+// quotaErr makes the quota errors replied with 552
+func quotaErr(err error) error {
+	if errs.IsQuotaError(err) {
+		return fmt.Errorf("%w: %w", ftpserver.ErrStorageExceeded, err)
 	}
+	return err
 }
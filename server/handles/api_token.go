@@ -0,0 +1,109 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListMyAPITokens(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokensByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
+
+type CreateAPITokenReq struct {
+	Name     string `json:"name" binding:"required"`
+	BasePath string `json:"base_path"`
+	// Permission defaults to the current permission of the user
+	Permission *int32 `json:"permission"`
+	// ExpiresAt is a unix timestamp, 0 means never expires
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+type CreateAPITokenResp struct {
+	model.APIToken
+	Token             string `json:"token"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+}
+
+func CreateMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req CreateAPITokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t := model.APIToken{
+		UserID:     userObj.ID,
+		Name:       req.Name,
+		BasePath:   utils.FixAndCleanPath(req.BasePath),
+		Permission: userObj.Permission,
+	}
+	if req.Permission != nil {
+		t.Permission = *req.Permission & userObj.Permission
+	}
+	if req.ExpiresAt != 0 {
+		expiresAt := time.Unix(req.ExpiresAt, 0)
+		if expiresAt.Before(time.Now()) {
+			common.ErrorStrResp(c, "expires_at is in the past", 400)
+			return
+		}
+		t.ExpiresAt = &expiresAt
+	}
+	token, err := op.CreateAPIToken(&t)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, CreateAPITokenResp{
+		APIToken:          t,
+		Token:             token,
+		S3SecretAccessKey: op.APITokenS3Secret(&t),
+	})
+}
+
+func DeleteMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteAPITokenByIdAndUserId(uint(id), userObj.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -298,6 +298,115 @@ func FsArchiveDecompress(c *gin.Context) {
 	})
 }
 
+type ArchiveCompressReq struct {
+	SrcDir      string   `json:"src_dir" form:"src_dir"`
+	DstDir      string   `json:"dst_dir" form:"dst_dir"`
+	Name        []string `json:"name" form:"name"`
+	ArchiveName string   `json:"archive_name" form:"archive_name"`
+	Format      string   `json:"format" form:"format"`
+	ArchivePass string   `json:"archive_pass" form:"archive_pass"`
+	Overwrite   bool     `json:"overwrite" form:"overwrite"`
+	Password    string   `json:"password" form:"password"`
+}
+
+// archiveCompressNames resolves the names to compress in the src dir, every name must be inside the src dir,
+// the returned names are relative to the resolved src dir
+func archiveCompressNames(user *model.User, srcDir string, names []string) (string, []string, error) {
+	reqDir, err := user.JoinPath(srcDir)
+	if err != nil {
+		return "", nil, err
+	}
+	ret := make([]string, 0, len(names))
+	for _, name := range names {
+		reqPath, err := user.JoinPath(stdpath.Join(srcDir, name))
+		if err != nil {
+			return "", nil, err
+		}
+		if reqPath == reqDir || !utils.IsSubPath(reqDir, reqPath) {
+			return "", nil, errors.WithMessagef(errs.PermissionDenied, "invalid name: %s", name)
+		}
+		ret = append(ret, strings.TrimPrefix(reqPath, utils.PathAddSeparatorSuffix(reqDir)))
+	}
+	return reqDir, ret, nil
+}
+
+func FsArchiveCompress(c *gin.Context) {
+	var req ArchiveCompressReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Name) == 0 {
+		common.ErrorStrResp(c, "empty file names", 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "zip"
+	}
+	if req.ArchiveName == "" {
+		base := stdpath.Base(req.SrcDir)
+		if len(req.Name) == 1 {
+			base = req.Name[0]
+		} else if base == "/" || base == "." {
+			base = "archive"
+		}
+		req.ArchiveName = base + "." + req.Format
+	}
+	if stdpath.Base(req.ArchiveName) != req.ArchiveName {
+		common.ErrorStrResp(c, "invalid archive name", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	// compressing creates a new object, the write permission of a meta is not enough
+	if !user.CanDecompress() || !user.CanWrite() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	srcDir, names, err := archiveCompressNames(user, req.SrcDir, req.Name)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	srcMeta, err := op.GetNearestMeta(srcDir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	for _, name := range names {
+		if !common.CanAccess(user, srcMeta, stdpath.Join(srcDir, name), req.Password) {
+			common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+			return
+		}
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	t, err := fs.ArchiveCompress(c.Request.Context(), srcDir, dstDir, model.ArchiveCompressArgs{
+		Names:       names,
+		ArchiveName: req.ArchiveName,
+		Format:      req.Format,
+		Password:    req.ArchivePass,
+		Overwrite:   req.Overwrite,
+	})
+	if err != nil {
+		if errors.Is(err, errs.UnknownArchiveFormat) || errors.Is(err, errs.NotSupport) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	tasks := make([]task.TaskExtensionInfo, 0, 1)
+	if t != nil {
+		tasks = append(tasks, t)
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfos(tasks),
+	})
+}
+
 func ArchiveDown(c *gin.Context) {
 	archiveRawPath := c.Request.Context().Value(conf.PathKey).(string)
 	innerPath := utils.FixAndCleanPath(c.Query("inner"))
@@ -0,0 +1,404 @@
+package handles
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	archiveFormatZip   = "zip"
+	archiveFormatTarGz = "tar.gz"
+)
+
+type ArchiveDownloadReq struct {
+	SrcDir   string   `json:"src_dir" form:"src_dir"`
+	Names    []string `json:"names" form:"names"`
+	Format   string   `json:"format" form:"format"`
+	Password string   `json:"password" form:"password"`
+}
+
+type ArchiveDownloadResp struct {
+	RawURL string `json:"raw_url"`
+}
+
+func checkArchiveFormat(format string) (string, error) {
+	switch format {
+	case "", archiveFormatZip:
+		return archiveFormatZip, nil
+	case archiveFormatTarGz, "tgz":
+		return archiveFormatTarGz, nil
+	default:
+		return "", errors.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+// FsArchiveDownload checks the permission of the selection and returns a signed /z link streaming it
+func FsArchiveDownload(c *gin.Context) {
+	var req ArchiveDownloadReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	format, err := checkArchiveFormat(req.Format)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() && user.Disabled {
+		common.ErrorStrResp(c, "Guest user is disabled, login please", 401)
+		return
+	}
+	reqDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, reqDir, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	for _, name := range req.Names {
+		if name == "" || strings.Contains(name, "/") {
+			common.ErrorStrResp(c, fmt.Sprintf("invalid name: %s", name), 400)
+			return
+		}
+		if !common.CanAccess(user, meta, stdpath.Join(reqDir, name), req.Password) {
+			common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+			return
+		}
+	}
+	query := url.Values{}
+	query.Set("format", format)
+	query.Set("user", user.Username)
+	query["names"] = req.Names
+	query.Set("sign", sign.SignArchiveDownload(archiveDownloadSignData(reqDir, query)))
+	common.SuccessResp(c, ArchiveDownloadResp{
+		RawURL: fmt.Sprintf("%s/z%s?%s", common.GetApiUrl(c), utils.EncodePath(reqDir, true), query.Encode()),
+	})
+}
+
+func archiveDownloadSignData(reqDir string, query url.Values) string {
+	q := url.Values{}
+	for k, v := range query {
+		if k != "sign" {
+			q[k] = v
+		}
+	}
+	return reqDir + "?" + q.Encode()
+}
+
+// ArchiveDownload streams the selection signed by FsArchiveDownload
+func ArchiveDownload(c *gin.Context) {
+	reqDir := c.Request.Context().Value(conf.PathKey).(string)
+	query := c.Request.URL.Query()
+	if err := sign.VerifyArchiveDownload(archiveDownloadSignData(reqDir, query), query.Get("sign")); err != nil {
+		common.ErrorPage(c, err, 401)
+		return
+	}
+	format, err := checkArchiveFormat(query.Get("format"))
+	if err != nil {
+		common.ErrorPage(c, err, 400)
+		return
+	}
+	user, err := op.GetUserByName(query.Get("user"))
+	if err != nil {
+		common.ErrorPage(c, err, 403)
+		return
+	}
+	if user.Disabled {
+		common.ErrorPage(c, errors.New("user is disabled"), 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorPage(c, err, 500, true)
+			return
+		}
+	}
+	ctx := context.WithValue(c.Request.Context(), conf.UserKey, user)
+	w := &archiveWalker{user: user, topMeta: meta}
+	var roots []archiveRoot
+	if names := query["names"]; len(names) > 0 {
+		for _, name := range names {
+			roots = append(roots, archiveRoot{path: stdpath.Join(reqDir, name), name: name})
+		}
+	} else {
+		objs, err := w.list(ctx, reqDir)
+		if err != nil {
+			common.ErrorPage(c, err, 500)
+			return
+		}
+		for _, obj := range objs {
+			roots = append(roots, archiveRoot{path: stdpath.Join(reqDir, obj.GetName()), name: obj.GetName(), obj: obj})
+		}
+	}
+	name := stdpath.Base(reqDir)
+	if reqDir == "/" {
+		name = "root"
+	}
+	if names := query["names"]; len(names) == 1 {
+		name = names[0]
+	}
+	w.serve(ctx, c, format, name, roots)
+}
+
+// sharingArchiveDown streams a shared folder or the root of a share with several files
+func sharingArchiveDown(c *gin.Context, s *model.Sharing, path string) {
+	format, err := checkArchiveFormat(c.Query("format"))
+	if err != nil {
+		common.ErrorPage(c, err, 400)
+		return
+	}
+	var roots []archiveRoot
+	name := s.ID
+	if path == "/" && len(s.Files) != 1 {
+		for _, f := range s.Files {
+			roots = append(roots, archiveRoot{path: f, name: stdpath.Base(f)})
+		}
+	} else {
+		unwrapPath, err := op.GetSharingUnwrapPath(s, path)
+		if err != nil {
+			common.ErrorPage(c, errors.New("failed get sharing unwrap path"), 500)
+			return
+		}
+		roots = append(roots, archiveRoot{path: unwrapPath, name: stdpath.Base(unwrapPath)})
+		name = stdpath.Base(unwrapPath)
+	}
+	_ = countAccess(c.ClientIP(), s)
+	w := &archiveWalker{}
+	w.serve(c.Request.Context(), c, format, name, roots)
+	recordAccess(c, s, model.ShareAccessDownload, path, int64(c.Writer.Size()))
+	sharing.AddBytesServed(s, int64(c.Writer.Size()))
+}
+
+type archiveRoot struct {
+	path string
+	name string
+	obj  model.Obj
+}
+
+// archiveWriter writes objects into an archive stream
+type archiveWriter interface {
+	WriteDir(name string, obj model.Obj) error
+	WriteFile(name string, obj model.Obj, r io.Reader) error
+	Close() error
+}
+
+type zipArchiveWriter struct {
+	*zip.Writer
+}
+
+func (z *zipArchiveWriter) WriteDir(name string, obj model.Obj) error {
+	_, err := z.CreateHeader(&zip.FileHeader{
+		Name:     name + "/",
+		Modified: obj.ModTime(),
+	})
+	return err
+}
+
+func (z *zipArchiveWriter) WriteFile(name string, obj model.Obj, r io.Reader) error {
+	// most files worth downloading are compressed already, storing them keeps the stream fast
+	w, err := z.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: obj.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = utils.CopyWithBuffer(w, r)
+	return err
+}
+
+type tarGzArchiveWriter struct {
+	tw *tar.Writer
+	gw *gzip.Writer
+}
+
+func (t *tarGzArchiveWriter) WriteDir(name string, obj model.Obj) error {
+	return t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0o755,
+		ModTime:  obj.ModTime(),
+	})
+}
+
+func (t *tarGzArchiveWriter) WriteFile(name string, obj model.Obj, r io.Reader) error {
+	err := t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     obj.GetSize(),
+		ModTime:  obj.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	n, err := utils.CopyWithBufferN(t.tw, r, obj.GetSize())
+	if err == nil && n != obj.GetSize() {
+		err = errs.StreamIncomplete
+	}
+	return err
+}
+
+func (t *tarGzArchiveWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	return t.gw.Close()
+}
+
+// archiveWalker walks the selection with fs.List/fs.Link and writes it into an archive.
+// user is nil for shares, then no meta and permission checks are applied.
+type archiveWalker struct {
+	user    *model.User
+	topMeta *model.Meta
+	w       archiveWriter
+}
+
+func (a *archiveWalker) serve(ctx context.Context, c *gin.Context, format, name string, roots []archiveRoot) {
+	fileName := name + ".zip"
+	contentType := "application/zip"
+	if format == archiveFormatTarGz {
+		fileName = name + ".tar.gz"
+		contentType = "application/gzip"
+	}
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(fileName))
+	c.Header("Content-Type", contentType)
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
+	c.Status(200)
+	if c.Request.Method == "HEAD" {
+		return
+	}
+	if format == archiveFormatTarGz {
+		gw := gzip.NewWriter(c.Writer)
+		a.w = &tarGzArchiveWriter{tw: tar.NewWriter(gw), gw: gw}
+	} else {
+		a.w = &zipArchiveWriter{Writer: zip.NewWriter(c.Writer)}
+	}
+	err := a.walkRoots(ctx, roots)
+	if err == nil {
+		err = a.w.Close()
+	}
+	if err != nil {
+		// the status has been sent, the client can only notice a truncated archive
+		log.Errorf("failed stream archive %s: %+v", fileName, err)
+		c.Abort()
+	}
+}
+
+func (a *archiveWalker) walkRoots(ctx context.Context, roots []archiveRoot) error {
+	for _, r := range roots {
+		obj := r.obj
+		if obj == nil {
+			if !a.canAccess(r.path) {
+				continue
+			}
+			var err error
+			obj, err = fs.Get(ctx, r.path, &fs.GetArgs{})
+			if err != nil {
+				return err
+			}
+		}
+		if err := a.walk(ctx, r.path, r.name, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *archiveWalker) walk(ctx context.Context, reqPath, name string, obj model.Obj) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !obj.IsDir() {
+		return a.writeFile(ctx, reqPath, name, obj)
+	}
+	if err := a.w.WriteDir(name, obj); err != nil {
+		return err
+	}
+	objs, err := a.list(ctx, reqPath)
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		p := stdpath.Join(reqPath, o.GetName())
+		if o.IsDir() && !a.canAccess(p) {
+			continue
+		}
+		if err = a.walk(ctx, p, name+"/"+o.GetName(), o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *archiveWalker) list(ctx context.Context, reqPath string) ([]model.Obj, error) {
+	if a.user != nil {
+		meta, _ := op.GetNearestMeta(reqPath)
+		ctx = context.WithValue(ctx, conf.MetaKey, meta)
+	}
+	return fs.List(ctx, reqPath, &fs.ListArgs{})
+}
+
+// canAccess checks hide rules and passwords, the meta of the selected folder has been verified before signing
+func (a *archiveWalker) canAccess(reqPath string) bool {
+	if a.user == nil {
+		return true
+	}
+	meta, _ := op.GetNearestMeta(reqPath)
+	password := ""
+	if meta != nil && a.topMeta != nil && meta.ID == a.topMeta.ID {
+		password = meta.Password
+	}
+	return common.CanAccess(a.user, meta, reqPath, password)
+}
+
+func (a *archiveWalker) writeFile(ctx context.Context, reqPath, name string, obj model.Obj) error {
+	link, file, err := fs.Link(ctx, reqPath, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: file,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	defer ss.Close()
+	return a.w.WriteFile(name, obj, ss)
+}
@@ -0,0 +1,24 @@
+package handles
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestArchiveCompressNames(t *testing.T) {
+	user := &model.User{BasePath: "/user"}
+	srcDir, names, err := archiveCompressNames(user, "/docs", []string{"a.txt", "sub/b.txt", "sub/../c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srcDir != "/user/docs" || !reflect.DeepEqual(names, []string{"a.txt", "sub/b.txt", "c"}) {
+		t.Errorf("archiveCompressNames = %s, %q", srcDir, names)
+	}
+	for _, name := range []string{"../other", "../../other", "..", ".", "", "a/../.."} {
+		if _, _, err = archiveCompressNames(user, "/docs", []string{"a.txt", name}); err == nil {
+			t.Errorf("name %q is accepted", name)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handles
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditLogFilter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := db.GetAuditLogs(req.AuditLogFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+type ExportAuditLogsReq struct {
+	model.AuditLogFilter
+	// Format is csv or json, defaults to csv
+	Format string `json:"format" form:"format"`
+}
+
+func ExportAuditLogs(c *gin.Context) {
+	var req ExportAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	var write func(logs []model.AuditLog) error
+	var finish func() error
+	switch req.Format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write([]string{"id", "time", "username", "ip", "protocol", "operation", "src_path", "dst_path", "success", "message"})
+		write = func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				if err := w.Write([]string{
+					strconv.FormatUint(uint64(l.ID), 10), l.CreatedAt.Format(time.RFC3339), l.Username, l.IP,
+					l.Protocol, l.Operation, l.SrcPath, l.DstPath, strconv.FormatBool(l.Success), l.Message,
+				}); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+		finish = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	case "json":
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(c.Writer)
+		sep := "["
+		write = func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				if _, err := c.Writer.WriteString(sep); err != nil {
+					return err
+				}
+				sep = ","
+				if err := enc.Encode(l); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		finish = func() error {
+			if sep == "[" {
+				_, err := c.Writer.WriteString("[]")
+				return err
+			}
+			_, err := c.Writer.WriteString("]")
+			return err
+		}
+	default:
+		common.ErrorStrResp(c, fmt.Sprintf("unsupported format: %s", req.Format), 400)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.%s"`, time.Now().Format("20060102150405"), req.Format))
+	c.Status(200)
+	err := db.IterAuditLogs(req.AuditLogFilter, 500, write)
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		// the header has been sent, so only log it
+		log.Errorf("failed export audit logs: %+v", err)
+	}
+}
+
+type ClearAuditLogsReq struct {
+	// Before is a unix timestamp, the logs created before it are deleted
+	Before int64 `json:"before" form:"before" binding:"required"`
+}
+
+func ClearAuditLogs(c *gin.Context) {
+	var req ClearAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	n, err := db.DeleteAuditLogsBefore(time.Unix(req.Before, 0))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"deleted": n,
+	})
+}
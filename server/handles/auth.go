@@ -8,6 +8,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
@@ -82,7 +83,8 @@ func loginHash(c *gin.Context, req *LoginReq) {
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp   bool         `json:"otp"`
+	Usage *quota.Usage `json:"usage"`
 }
 
 // CurrentUser get current user by token
@@ -96,6 +98,12 @@ func CurrentUser(c *gin.Context) {
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	usage, err := quota.GetUsage(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	userResp.Usage = usage
 	common.SuccessResp(c, userResp)
 }
 
@@ -0,0 +1,69 @@
+package handles
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/bundle"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportBundle downloads the configuration as a bundle file,
+// the query redact replaces the confidential driver fields and format is json or yaml
+func ExportBundle(c *gin.Context) {
+	format := c.DefaultQuery("format", bundle.FormatJSON)
+	b, err := bundle.Export(c.Query("redact") == "true")
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	data, err := bundle.Marshal(b, format)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	contentType := "application/json"
+	if format == bundle.FormatYAML {
+		contentType = "application/yaml"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="openlist-%s.%s"`, b.ExportedAt.Format("20060102-150405"), format))
+	c.Data(200, contentType, data)
+}
+
+// ImportBundle imports the bundle in the body, the format is taken from the query format or the content type.
+// The bundle is merged into the configuration unless the query replace is true.
+func ImportBundle(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = bundle.FormatJSON
+		if strings.Contains(c.ContentType(), "yaml") {
+			format = bundle.FormatYAML
+		}
+	}
+	replace := c.Query("replace") == "true"
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	b, err := bundle.Unmarshal(data, format)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	res, err := bundle.Import(b, replace)
+	mode := "merge"
+	if replace {
+		mode = "replace"
+	}
+	audit.Record(c.Request.Context(), audit.OpBundleImport, mode, "", err)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	bundle.Reload()
+	common.SuccessResp(c, res)
+}
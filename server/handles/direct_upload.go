@@ -4,6 +4,7 @@ import (
 	"net/url"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -46,6 +47,10 @@ func FsGetDirectUploadInfo(c *gin.Context) {
 		}
 	}
 	directUploadInfo, err := fs.GetDirectUploadInfo(c, req.Tool, path, req.FileName, req.FileSize)
+	if errs.IsQuotaError(err) {
+		common.ErrorResp(c, err, 413)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -114,6 +114,10 @@ func FsStream(c *gin.Context) {
 	} else {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
+	if errs.IsQuotaError(err) {
+		common.ErrorResp(c, err, 413)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -214,6 +218,10 @@ func FsForm(c *gin.Context) {
 	} else {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
+	if errs.IsQuotaError(err) {
+		common.ErrorResp(c, err, 413)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
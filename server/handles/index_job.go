@@ -0,0 +1,110 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/index_job"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListIndexJobs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	jobs, total, err := db.GetIndexJobs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: jobs,
+		Total:   total,
+	})
+}
+
+func getIndexJob(c *gin.Context) (*model.IndexJob, bool) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	j, err := db.GetIndexJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return nil, false
+	}
+	return j, true
+}
+
+func GetIndexJob(c *gin.Context) {
+	if j, ok := getIndexJob(c); ok {
+		common.SuccessResp(c, j)
+	}
+}
+
+func CreateIndexJob(c *gin.Context) {
+	var req model.IndexJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := index_job.Validate(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := index_job.CreateIndexJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"id": req.ID,
+	})
+}
+
+func UpdateIndexJob(c *gin.Context) {
+	var req model.IndexJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := index_job.Validate(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := index_job.UpdateIndexJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteIndexJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.DeleteIndexJobById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func RunIndexJob(c *gin.Context) {
+	j, ok := getIndexJob(c)
+	if !ok {
+		return
+	}
+	if err := index_job.Start(j); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
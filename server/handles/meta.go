@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -43,7 +44,9 @@ func CreateMeta(c *gin.Context) {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
-	if err := op.CreateMeta(&req); err != nil {
+	err = op.CreateMeta(&req)
+	audit.Record(c.Request.Context(), audit.OpMetaCreate, req.Path, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -61,7 +64,9 @@ func UpdateMeta(c *gin.Context) {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
-	if err := op.UpdateMeta(&req); err != nil {
+	err = op.UpdateMeta(&req)
+	audit.Record(c.Request.Context(), audit.OpMetaUpdate, req.Path, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -86,7 +91,9 @@ func DeleteMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteMetaById(uint(id)); err != nil {
+	err = op.DeleteMetaById(uint(id))
+	audit.Record(c.Request.Context(), audit.OpMetaDelete, idStr, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
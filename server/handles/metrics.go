@@ -0,0 +1,25 @@
+package handles
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+var metricsHandler = metrics.Handler()
+
+func Metrics(c *gin.Context) {
+	if token := conf.Conf.Metrics.Token; token != "" {
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+	}
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
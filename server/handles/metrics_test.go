@@ -0,0 +1,77 @@
+package handles
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetrics(t *testing.T) {
+	if conf.Conf == nil {
+		conf.Conf = conf.DefaultConfig("data")
+	}
+	token := conf.Conf.Metrics.Token
+	defer func() { conf.Conf.Metrics.Token = token }()
+	conf.Conf.Metrics.Token = "secret"
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", Metrics)
+	get := func(auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, auth := range []string{"", "Bearer wrong", "secret-but-longer"} {
+		if w := get(auth); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("Authorization %q: status = %d, want 401", auth, w.Code)
+		}
+	}
+
+	// the bytes served by a request are counted
+	r.GET("/d", func(c *gin.Context) {
+		rrc := &model.RangeReadCloser{RangeReader: stream.GetRangeReaderFromMFile(5, strings.NewReader("hello"))}
+		_ = net.ServeHTTP(c.Writer, c.Request, "a.txt", time.Now(), 5, rrc)
+	})
+	served := func() float64 {
+		w := get("Bearer secret")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		for _, line := range strings.Split(w.Body.String(), "\n") {
+			if value, ok := strings.CutPrefix(line, `openlist_transferred_bytes_total{via="serve"} `); ok {
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return v
+			}
+		}
+		return 0
+	}
+	before := served()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/d", nil))
+	if got := served(); got != before+5 {
+		t.Errorf("transferred bytes = %v after a request, want %v", got, before+5)
+	}
+
+	// the metrics are public without a token
+	conf.Conf.Metrics.Token = ""
+	if w := get(""); w.Code != http.StatusOK {
+		t.Errorf("status without a token = %d, want 200", w.Code)
+	}
+}
@@ -424,6 +424,40 @@ func SetThunderBrowser(c *gin.Context) {
 	common.SuccessResp(c, "ok")
 }
 
+type SetYtDlpReq struct {
+	Path   string `json:"path" form:"path"`
+	Format string `json:"format" form:"format"`
+	Args   string `json:"args" form:"args"`
+}
+
+func SetYtDlp(c *gin.Context) {
+	var req SetYtDlpReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	items := []model.SettingItem{
+		{Key: conf.YtDlpPath, Value: req.Path, Type: conf.TypeString, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.YtDlpFormat, Value: req.Format, Type: conf.TypeString, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.YtDlpArgs, Value: req.Args, Type: conf.TypeString, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+	}
+	if err := op.SaveSettingItems(items); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	_tool, err := tool.Tools.Get("yt-dlp")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	version, err := _tool.Init()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, version)
+}
+
 func OfflineDownloadTools(c *gin.Context) {
 	tools := tool.Tools.Names()
 	common.SuccessResp(c, tools)
@@ -20,11 +20,6 @@ type SearchReq struct {
 	Password string `json:"password"`
 }
 
-type SearchResp struct {
-	model.SearchNode
-	Type int `json:"type"`
-}
-
 func Search(c *gin.Context) {
 	var (
 		req SearchReq
@@ -54,6 +49,10 @@ func Search(c *gin.Context) {
 		if !strings.HasPrefix(node.Parent, user.BasePath) {
 			continue
 		}
+		// the trash may be indexed by the old versions
+		if op.IsTrashMountPath(path.Join(node.Parent, node.Name)) {
+			continue
+		}
 		meta, err := op.GetNearestMeta(node.Parent)
 		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
 			continue
@@ -64,14 +63,15 @@ func Search(c *gin.Context) {
 		filteredNodes = append(filteredNodes, node)
 	}
 	common.SuccessResp(c, common.PageResp{
-		Content: utils.MustSliceConvert(filteredNodes, nodeToSearchResp),
+		Content: utils.MustSliceConvert(filteredNodes, fillObjType),
 		Total:   total,
 	})
 }
 
-func nodeToSearchResp(node model.SearchNode) SearchResp {
-	return SearchResp{
-		SearchNode: node,
-		Type:       utils.GetObjType(node.Name, node.IsDir),
+// fillObjType sets the type of the nodes indexed before the type was stored
+func fillObjType(node model.SearchNode) model.SearchNode {
+	if node.ObjType == conf.UNKNOWN {
+		node.ObjType = utils.GetObjType(node.Name, node.IsDir)
 	}
+	return node
 }
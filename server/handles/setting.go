@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -19,11 +20,14 @@ import (
 func ResetToken(c *gin.Context) {
 	token := random.Token()
 	item := model.SettingItem{Key: "token", Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE}
-	if err := op.SaveSettingItem(&item); err != nil {
+	err := op.SaveSettingItem(&item)
+	audit.Record(c.Request.Context(), audit.OpResetToken, item.Key, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
 	sign.Instance()
+	sign.InstanceArchiveDownload()
 	common.SuccessResp(c, token)
 }
 
@@ -53,7 +57,13 @@ func SaveSettings(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.SaveSettingItems(req); err != nil {
+	keys := make([]string, len(req))
+	for i := range req {
+		keys[i] = req[i].Key
+	}
+	err := op.SaveSettingItems(req)
+	audit.Record(c.Request.Context(), audit.OpSettingSave, strings.Join(keys, ","), "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -135,7 +145,9 @@ func DefaultSettings(c *gin.Context) {
 
 func DeleteSetting(c *gin.Context) {
 	key := c.Query("key")
-	if err := op.DeleteSettingItemByKey(key); err != nil {
+	err := op.DeleteSettingItemByKey(key)
+	audit.Record(c.Request.Context(), audit.OpSettingDelete, key, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
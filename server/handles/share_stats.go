@@ -0,0 +1,66 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ownSharing returns the sharing of the query id if the user is its creator or the admin
+func ownSharing(c *gin.Context) (*model.Sharing, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(c.Query("id"))
+	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return nil, false
+	}
+	return s, true
+}
+
+// ShareStats returns the visits of the sharing aggregated per file and per day in the last days, 30 by default
+func ShareStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 366 {
+		common.ErrorStrResp(c, "days must be between 1 and 366", 400)
+		return
+	}
+	s, ok := ownSharing(c)
+	if !ok {
+		return
+	}
+	stats, err := sharing.Stats(s, days)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, stats)
+}
+
+// ShareAccessLog lists the access log of the sharing from the newest
+func ShareAccessLog(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	s, ok := ownSharing(c)
+	if !ok {
+		return
+	}
+	accesses, total, err := db.GetShareAccesses(s.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: accesses,
+		Total:   total,
+	})
+}
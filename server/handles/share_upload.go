@@ -0,0 +1,175 @@
+package handles
+
+import (
+	"context"
+	"io"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// SharingPut uploads the body into a folder of an upload-enabled sharing, the header File-Path is
+// /{sid}/{path in the sharing} and Password is the share code. The file is uploaded as the creator of the
+// sharing, so it counts towards the quota of the creator, and an existing file is never overwritten.
+func SharingPut(c *gin.Context) {
+	defer func() {
+		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		}
+		_ = c.Request.Body.Close()
+	}()
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sid, path, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if sid == "" {
+		common.ErrorStrResp(c, "invalid share id", 400)
+		return
+	}
+	path = utils.FixAndCleanPath(path)
+	dir, name := stdpath.Split(path)
+	if name == "" {
+		common.ErrorStrResp(c, "file name is required", 400)
+		return
+	}
+	s, err := op.GetSharingById(sid)
+	if err != nil {
+		err = errs.SharingNotFound
+	} else if !s.Valid() {
+		err = errs.InvalidSharing
+	} else if err = sharing.Verify(s, c.GetHeader("Password"), c.ClientIP()); err == nil && !s.Upload {
+		err = errs.UploadForbidden
+	}
+	var creator *model.User
+	if err == nil {
+		// the sharing is cached, the creator may be disabled or lose the permission since then
+		creator, err = getShareCreator(s)
+	}
+	if dealError(c, err) {
+		return
+	}
+	if shouldIgnoreSystemFile(name) {
+		common.ErrorStrResp(c, errs.IgnoredSystemFile.Error(), 403)
+		return
+	}
+	if !s.AllowExtension(name) {
+		common.ErrorStrResp(c, "the file type is not allowed by the share", 403)
+		return
+	}
+	size := c.Request.ContentLength
+	declared := size
+	if size < 0 {
+		if sizeStr := c.GetHeader("X-File-Size"); sizeStr != "" {
+			declared, err = strconv.ParseInt(sizeStr, 10, 64)
+			if err != nil || declared < 0 {
+				common.ErrorStrResp(c, "invalid X-File-Size", 400)
+				return
+			}
+		}
+	}
+	if s.UploadMaxSize > 0 {
+		if declared < 0 {
+			common.ErrorStrResp(c, "the file size is required by the share", 411)
+			return
+		}
+		if declared > s.UploadMaxSize {
+			common.ErrorResp(c, errs.FileTooLarge, 413)
+			return
+		}
+	}
+	unwrapDir, err := op.GetSharingUnwrapPath(s, dir)
+	if err != nil {
+		common.ErrorStrResp(c, "cannot upload to the root of a share with several files", 403)
+		return
+	}
+	// upload as the creator, the audit log and the quota go to the creator
+	ctx := context.WithValue(c.Request.Context(), conf.UserKey, creator)
+	ctx = context.WithValue(ctx, conf.NoOverwriteKey, struct{}{})
+	if obj, err := fs.Get(ctx, unwrapDir, &fs.GetArgs{NoLog: true}); err != nil || !obj.IsDir() {
+		common.ErrorStrResp(c, "the upload folder does not exist in the share", 404)
+		return
+	}
+	meta, err := op.GetNearestMeta(unwrapDir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !creator.CanWrite() && !common.CanWrite(meta, unwrapDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	mimetype := c.GetHeader("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	// the body of unknown length is cached by the upload, so the bytes read are the size of the file
+	body := newShareUploadBody(c.Request.Body, size, declared)
+	err = fs.PutDirectly(ctx, unwrapDir, &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:   body,
+		Mimetype: mimetype,
+	})
+	if errs.IsQuotaError(err) {
+		common.ErrorResp(c, err, 413)
+		return
+	}
+	if errors.Is(err, errs.ObjectAlreadyExists) {
+		// checked by the put itself rather than beforehand, so a hidden share can't be probed without uploading
+		common.ErrorStrResp(c, "file exists", 409)
+		return
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	recordAccess(c, s, model.ShareAccessUpload, path, body.n)
+	common.SuccessResp(c)
+}
+
+func getShareCreator(s *model.Sharing) (*model.User, error) {
+	creator, err := op.GetUserById(s.CreatorId)
+	if err != nil || creator.Disabled || !creator.CanShare() {
+		return nil, errs.InvalidSharing
+	}
+	return creator, nil
+}
+
+// shareUploadBody counts the bytes of the body, and fails with errs.FileTooLarge once they exceed
+// the size declared by X-File-Size, which can't be trusted for a chunked body
+type shareUploadBody struct {
+	r io.Reader
+	n int64
+}
+
+func newShareUploadBody(body io.Reader, size, declared int64) *shareUploadBody {
+	if size < 0 && declared >= 0 {
+		body = quota.LimitReader(body, declared, errs.FileTooLarge)
+	}
+	return &shareUploadBody{r: body}
+}
+
+func (b *shareUploadBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	return n, err
+}
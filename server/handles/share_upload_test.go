@@ -0,0 +1,34 @@
+package handles
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/pkg/errors"
+)
+
+func TestShareUploadBody(t *testing.T) {
+	tests := []struct {
+		size, declared int64
+		body           string
+		err            error
+	}{
+		// the length of the request is enforced by the http server
+		{size: 5, declared: 5, body: "hello"},
+		{size: -1, declared: -1, body: "hello"},
+		{size: -1, declared: 5, body: "hello"},
+		{size: -1, declared: 4, body: "hello", err: errs.FileTooLarge},
+	}
+	for _, tt := range tests {
+		body := newShareUploadBody(strings.NewReader(tt.body), tt.size, tt.declared)
+		_, err := io.Copy(io.Discard, body)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("size %d, declared %d: err = %v, want %v", tt.size, tt.declared, err, tt.err)
+		}
+		if tt.err == nil && body.n != int64(len(tt.body)) {
+			t.Errorf("size %d, declared %d: counted %d bytes", tt.size, tt.declared, body.n)
+		}
+	}
+}
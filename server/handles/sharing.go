@@ -1,11 +1,14 @@
 package handles
 
 import (
+	"context"
 	"fmt"
+	neturl "net/url"
 	stdpath "path"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
@@ -13,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/go-cache"
@@ -34,12 +38,13 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 		return
 	}
 	_ = countAccess(c.ClientIP(), s)
+	recordAccess(c, s, model.ShareAccessGet, path, 0)
 	url := ""
 	if !obj.IsDir() {
 		fakePath := fmt.Sprintf("/%s/%s", sid, path)
 		url = fmt.Sprintf("%s/sd%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
-		if s.Pwd != "" {
-			url += "?pwd=" + s.Pwd
+		if req.Password != "" {
+			url += "?pwd=" + neturl.QueryEscape(req.Password)
 		}
 	}
 	thumb, _ := model.GetThumb(obj)
@@ -78,6 +83,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 		return
 	}
 	_ = countAccess(c.ClientIP(), s)
+	recordAccess(c, s, model.ShareAccessList, path, 0)
 	total, objs := pagination(objs, &req.PageReq)
 	common.SuccessResp(c, FsListResp{
 		Content: utils.MustSliceConvert(objs, func(obj model.Obj) ObjResp {
@@ -98,7 +104,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 		Total:    int64(total),
 		Readme:   s.Readme,
 		Header:   s.Header,
-		Write:    false,
+		Write:    s.Upload,
 		Provider: "unknown",
 	})
 }
@@ -131,10 +137,11 @@ func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
 		return
 	}
 	_ = countAccess(c.ClientIP(), s)
+	recordAccess(c, s, model.ShareAccessArchive, path, 0)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
 	url := fmt.Sprintf("%s/sad%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
-	if s.Pwd != "" {
-		url += "?pwd=" + s.Pwd
+	if req.Password != "" {
+		url += "?pwd=" + neturl.QueryEscape(req.Password)
 	}
 	common.SuccessResp(c, ArchiveMetaResp{
 		Comment:     ret.GetComment(),
@@ -177,6 +184,7 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 		return
 	}
 	_ = countAccess(c.ClientIP(), s)
+	recordAccess(c, s, model.ShareAccessArchive, path, 0)
 	total, objs := pagination(objs, &req.PageReq)
 	ret, _ := utils.SliceConvert(objs, func(src model.Obj) (ObjResp, error) {
 		return toObjsRespWithoutSignAndThumb(src), nil
@@ -196,15 +204,17 @@ func SharingDown(c *gin.Context) {
 	if err == nil {
 		if !s.Valid() {
 			err = errs.InvalidSharing
-		} else if !s.Verify(pwd) {
-			err = errs.WrongShareCode
-		} else if len(s.Files) != 1 && path == "/" {
-			err = errors.New("cannot get sharing root link")
+		} else if err = sharing.Verify(s, pwd, c.ClientIP()); err == nil && s.HideFiles {
+			err = errs.SharingHidden
 		}
 	}
 	if dealErrorPage(c, err) {
 		return
 	}
+	if len(s.Files) != 1 && path == "/" {
+		sharingArchiveDown(c, s, path)
+		return
+	}
 	unwrapPath, err := op.GetSharingUnwrapPath(s, path)
 	if err != nil {
 		common.ErrorPage(c, errors.New("failed get sharing unwrap path"), 500)
@@ -214,11 +224,18 @@ func SharingDown(c *gin.Context) {
 	if dealErrorPage(c, err) {
 		return
 	}
-	if setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
-		if _, ok := c.GetQuery("d"); !ok {
+	if obj, err := op.Get(c.Request.Context(), storage, actualPath); err == nil && obj.IsDir() {
+		sharingArchiveDown(c, s, path)
+		return
+	}
+	// the downloads of a sharing with download limits are proxied to enforce them
+	limited := s.HasDownloadLimit()
+	if limited || setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
+		if _, ok := c.GetQuery("d"); !ok && !limited {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), unwrapPath); url != "" {
 				c.Redirect(302, url)
 				_ = countAccess(c.ClientIP(), s)
+				recordDownload(c, s, path, 0)
 				return
 			}
 		}
@@ -232,6 +249,8 @@ func SharingDown(c *gin.Context) {
 		}
 		_ = countAccess(c.ClientIP(), s)
 		proxy(c, link, obj, storage.GetStorage().ProxyRange)
+		recordDownload(c, s, path, int64(c.Writer.Size()))
+		sharing.AddBytesServed(s, int64(c.Writer.Size()))
 	} else {
 		link, _, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
 			IP:       c.ClientIP(),
@@ -245,6 +264,7 @@ func SharingDown(c *gin.Context) {
 		}
 		_ = countAccess(c.ClientIP(), s)
 		redirect(c, link)
+		recordDownload(c, s, path, 0)
 	}
 }
 
@@ -263,8 +283,10 @@ func SharingArchiveExtract(c *gin.Context) {
 	if err == nil {
 		if !s.Valid() {
 			err = errs.InvalidSharing
-		} else if !s.Verify(pwd) {
-			err = errs.WrongShareCode
+		} else if err = sharing.Verify(s, pwd, c.ClientIP()); err != nil {
+			// the share code is wrong or the ip is locked
+		} else if s.HideFiles {
+			err = errs.SharingHidden
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot extract sharing root")
 		}
@@ -292,12 +314,13 @@ func SharingArchiveExtract(c *gin.Context) {
 		InnerPath: innerPath,
 	}
 	if _, ok := storage.(driver.ArchiveReader); ok {
-		if setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
+		if s.HasDownloadLimit() || setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
 			link, obj, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
 			if dealErrorPage(c, err) {
 				return
 			}
 			proxy(c, link, obj, storage.GetStorage().ProxyRange)
+			sharing.AddBytesServed(s, int64(c.Writer.Size()))
 		} else {
 			args.Redirect = true
 			link, _, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
@@ -313,6 +336,7 @@ func SharingArchiveExtract(c *gin.Context) {
 		}
 		fileName := stdpath.Base(innerPath)
 		proxyInternalExtract(c, rc, size, fileName)
+		sharing.AddBytesServed(s, int64(c.Writer.Size()))
 	}
 }
 
@@ -323,8 +347,10 @@ func dealError(c *gin.Context, err error) bool {
 		common.ErrorStrResp(c, "the share does not exist", 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorStrResp(c, "the share has expired or is no longer valid", 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.SharingHidden) || errors.Is(err, errs.UploadForbidden) {
 		common.ErrorResp(c, err, 403)
+	} else if errors.Is(err, errs.SharingLocked) {
+		common.ErrorResp(c, err, 429)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorResp(c, err, 202)
 	} else {
@@ -340,8 +366,10 @@ func dealErrorPage(c *gin.Context, err error) bool {
 		common.ErrorPage(c, errors.New("the share does not exist"), 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorPage(c, errors.New("the share has expired or is no longer valid"), 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.SharingHidden) {
 		common.ErrorPage(c, err, 403)
+	} else if errors.Is(err, errs.SharingLocked) {
+		common.ErrorPage(c, err, 429)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorPage(c, err, 202)
 	} else {
@@ -354,6 +382,7 @@ type SharingResp struct {
 	*model.Sharing
 	CreatorName string `json:"creator"`
 	CreatorRole int    `json:"creator_role"`
+	HasPwd      bool   `json:"has_pwd"`
 }
 
 func GetSharing(c *gin.Context) {
@@ -368,6 +397,7 @@ func GetSharing(c *gin.Context) {
 		Sharing:     s,
 		CreatorName: s.Creator.Username,
 		CreatorRole: s.Creator.Role,
+		HasPwd:      s.HasPwd(),
 	})
 }
 
@@ -397,6 +427,7 @@ func ListSharings(c *gin.Context) {
 				Sharing:     &s,
 				CreatorName: s.Creator.Username,
 				CreatorRole: s.Creator.Role,
+				HasPwd:      s.HasPwd(),
 			}
 		}),
 		Total: total,
@@ -406,16 +437,25 @@ func ListSharings(c *gin.Context) {
 type UpdateSharingReq struct {
 	Files       []string   `json:"files"`
 	Expires     *time.Time `json:"expires"`
-	Pwd         string     `json:"pwd"`
+	Pwd         *string    `json:"pwd"`        // the new share code, null or empty keeps the current one
+	RemovePwd   bool       `json:"remove_pwd"` // removes the share code, the clients echo an empty pwd back
 	MaxAccessed int        `json:"max_accessed"`
 	Disabled    bool       `json:"disabled"`
 	Remark      string     `json:"remark"`
 	Readme      string     `json:"readme"`
 	Header      string     `json:"header"`
 	model.Sort
-	CreatorName string `json:"creator"`
-	Accessed    int    `json:"accessed"`
-	ID          string `json:"id"`
+	Upload           bool       `json:"upload"`
+	UploadMaxSize    int64      `json:"upload_max_size"`
+	UploadExtensions string     `json:"upload_extensions"`
+	HideFiles        bool       `json:"hide_files"`
+	NotBefore        *time.Time `json:"not_before"`
+	SpeedLimit       int        `json:"speed_limit"`
+	MaxBytes         int64      `json:"max_bytes"`
+	BytesServed      *int64     `json:"bytes_served"` // null keeps the current count
+	CreatorName      string     `json:"creator"`
+	Accessed         int        `json:"accessed"`
+	ID               string     `json:"id"`
 }
 
 func UpdateSharing(c *gin.Context) {
@@ -428,6 +468,14 @@ func UpdateSharing(c *gin.Context) {
 		common.ErrorStrResp(c, "must add at least 1 object", 400)
 		return
 	}
+	if req.UploadMaxSize < 0 || req.SpeedLimit < 0 || req.MaxBytes < 0 {
+		common.ErrorStrResp(c, "upload max size, speed limit and max bytes must not be negative", 400)
+		return
+	}
+	if req.NotBefore != nil && req.Expires != nil && !req.Expires.IsZero() && !req.NotBefore.Before(*req.Expires) {
+		common.ErrorStrResp(c, "not before must be earlier than expires", 400)
+		return
+	}
 	var user *model.User
 	var err error
 	reqUser := c.Request.Context().Value(conf.UserKey).(*model.User)
@@ -462,7 +510,11 @@ func UpdateSharing(c *gin.Context) {
 	}
 	s.Files = req.Files
 	s.Expires = req.Expires
-	s.Pwd = req.Pwd
+	if req.RemovePwd {
+		s.SetPwd("")
+	} else if req.Pwd != nil && *req.Pwd != "" {
+		s.SetPwd(*req.Pwd)
+	}
 	s.Accessed = req.Accessed
 	s.MaxAccessed = req.MaxAccessed
 	s.Disabled = req.Disabled
@@ -470,6 +522,16 @@ func UpdateSharing(c *gin.Context) {
 	s.Header = req.Header
 	s.Readme = req.Readme
 	s.Remark = req.Remark
+	s.Upload = req.Upload
+	s.UploadMaxSize = req.UploadMaxSize
+	s.UploadExtensions = req.UploadExtensions
+	s.HideFiles = req.HideFiles
+	s.NotBefore = req.NotBefore
+	s.SpeedLimit = req.SpeedLimit
+	s.MaxBytes = req.MaxBytes
+	if req.BytesServed != nil {
+		s.BytesServed = *req.BytesServed
+	}
 	s.Creator = user
 	if err = op.UpdateSharing(s); err != nil {
 		common.ErrorResp(c, err, 500)
@@ -478,6 +540,7 @@ func UpdateSharing(c *gin.Context) {
 			Sharing:     s,
 			CreatorName: s.Creator.Username,
 			CreatorRole: s.Creator.Role,
+			HasPwd:      s.HasPwd(),
 		})
 	}
 }
@@ -493,6 +556,14 @@ func CreateSharing(c *gin.Context) {
 		common.ErrorStrResp(c, "must add at least 1 object", 400)
 		return
 	}
+	if req.UploadMaxSize < 0 || req.SpeedLimit < 0 || req.MaxBytes < 0 {
+		common.ErrorStrResp(c, "upload max size, speed limit and max bytes must not be negative", 400)
+		return
+	}
+	if req.NotBefore != nil && req.Expires != nil && !req.Expires.IsZero() && !req.NotBefore.Before(*req.Expires) {
+		common.ErrorStrResp(c, "not before must be earlier than expires", 400)
+		return
+	}
 	var user *model.User
 	reqUser := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if reqUser.IsAdmin() && req.CreatorName != "" {
@@ -518,29 +589,42 @@ func CreateSharing(c *gin.Context) {
 	}
 	s := &model.Sharing{
 		SharingDB: &model.SharingDB{
-			ID:          req.ID,
-			Expires:     req.Expires,
-			Pwd:         req.Pwd,
-			Accessed:    req.Accessed,
-			MaxAccessed: req.MaxAccessed,
-			Disabled:    req.Disabled,
-			Sort:        req.Sort,
-			Remark:      req.Remark,
-			Readme:      req.Readme,
-			Header:      req.Header,
+			ID:               req.ID,
+			Expires:          req.Expires,
+			Accessed:         req.Accessed,
+			MaxAccessed:      req.MaxAccessed,
+			Disabled:         req.Disabled,
+			Sort:             req.Sort,
+			Remark:           req.Remark,
+			Readme:           req.Readme,
+			Header:           req.Header,
+			Upload:           req.Upload,
+			UploadMaxSize:    req.UploadMaxSize,
+			UploadExtensions: req.UploadExtensions,
+			HideFiles:        req.HideFiles,
+			NotBefore:        req.NotBefore,
+			SpeedLimit:       req.SpeedLimit,
+			MaxBytes:         req.MaxBytes,
 		},
 		Files:   req.Files,
 		Creator: user,
 	}
+	if req.Pwd != nil {
+		s.SetPwd(*req.Pwd)
+	}
 	var id string
-	if id, err = op.CreateSharing(s); err != nil {
+	id, err = op.CreateSharing(s)
+	audit.Record(c.Request.Context(), audit.OpShareCreate, strings.Join(req.Files, ","), id, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		s.ID = id
+		webhook.EmitShare(c.Request.Context(), webhook.EventShareCreate, id, s.Files, c.ClientIP())
 		common.SuccessResp(c, SharingResp{
 			Sharing:     s,
 			CreatorName: s.Creator.Username,
 			CreatorRole: s.Creator.Role,
+			HasPwd:      s.HasPwd(),
 		})
 	}
 }
@@ -556,6 +640,7 @@ func DeleteSharing(c *gin.Context) {
 	if err = op.DeleteSharing(sid); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
+		sharing.DeleteLimits(sid)
 		common.SuccessResp(c)
 	}
 }
@@ -589,7 +674,31 @@ func countAccess(ip string, s *model.Sharing) error {
 	if !ok {
 		AccessCache.Set(key, struct{}{}, cache.WithEx[interface{}](AccessCountDelay))
 		s.Accessed += 1
-		return op.UpdateSharing(s, true)
+		if err := op.UpdateSharing(s, true); err != nil {
+			return err
+		}
+		webhook.EmitShare(context.Background(), webhook.EventShareAccess, s.ID, s.Files, ip)
 	}
 	return nil
 }
+
+// recordAccess saves the visit to the access log of the sharing, the path is relative to the sharing
+func recordAccess(c *gin.Context, s *model.Sharing, action, path string, bytes int64) {
+	sharing.RecordAccess(&model.ShareAccess{
+		SharingID: s.ID,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Action:    action,
+		Path:      utils.FixAndCleanPath(path),
+		Bytes:     bytes,
+	})
+}
+
+// recordDownload records a download unless it is a range request resuming or seeking in the file,
+// so a download counts once however the client splits it
+func recordDownload(c *gin.Context, s *model.Sharing, path string, bytes int64) {
+	if r := c.GetHeader("Range"); r != "" && !strings.HasPrefix(r, "bytes=0-") {
+		return
+	}
+	recordAccess(c, s, model.ShareAccessDownload, path, bytes)
+}
@@ -0,0 +1,11 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/storage_health"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListStorageHealth(c *gin.Context) {
+	common.SuccessResp(c, storage_health.List())
+}
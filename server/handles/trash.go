@@ -0,0 +1,66 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ListTrashReq struct {
+	model.PageReq
+	model.TrashItemFilter
+}
+
+func ListTrash(c *gin.Context) {
+	var req ListTrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	items, total, err := db.GetTrashItems(req.TrashItemFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type TrashReq struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+func RestoreTrash(c *gin.Context) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	for _, id := range req.IDs {
+		if err := fs.RestoreTrash(c, id); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func PurgeTrash(c *gin.Context) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	for _, id := range req.IDs {
+		if err := fs.PurgeTrash(c, id); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
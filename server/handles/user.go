@@ -3,6 +3,8 @@ package handles
 import (
 	"strconv"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -42,7 +44,9 @@ func CreateUser(c *gin.Context) {
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
-	if err := op.CreateUser(&req); err != nil {
+	err := op.CreateUser(&req)
+	audit.Record(c.Request.Context(), audit.OpUserCreate, req.Username, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -78,7 +82,9 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
 	}
-	if err := op.UpdateUser(&req); err != nil {
+	err = op.UpdateUser(&req)
+	audit.Record(c.Request.Context(), audit.OpUserUpdate, req.Username, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -92,7 +98,9 @@ func DeleteUser(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteUserById(uint(id)); err != nil {
+	err = op.DeleteUserById(uint(id))
+	audit.Record(c.Request.Context(), audit.OpUserDelete, idStr, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -137,3 +145,17 @@ func DelUserCache(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+func ResetUserUsage(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.ResetUserUsedBytes(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,46 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListWebdavLocks(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	locks, total, err := db.GetWebdavLocks(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: locks,
+		Total:   total,
+	})
+}
+
+type ReleaseWebdavLocksReq struct {
+	Tokens []string `json:"tokens" binding:"required"`
+}
+
+// ReleaseWebdavLocks removes the locks regardless of the owners, for the locks left behind by the clients
+func ReleaseWebdavLocks(c *gin.Context) {
+	var req ReleaseWebdavLocksReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	for _, token := range req.Tokens {
+		if err := db.DeleteWebdavLockByToken(token); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
@@ -29,6 +29,18 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Next()
 			return
 		}
+		if op.IsAPIToken(token) {
+			user, t, err := op.GetUserByAPIToken(token)
+			if err != nil {
+				common.ErrorResp(c, err, 401)
+				c.Abort()
+				return
+			}
+			common.GinWithValue(c, conf.UserKey, user, conf.APITokenKey, t)
+			log.Debugf("use api token: %+v", user)
+			c.Next()
+			return
+		}
 		if token == "" {
 			guest, err := op.GetGuest()
 			if err != nil {
@@ -144,6 +156,20 @@ func AuthAdmin(c *gin.Context) {
 	if !user.IsAdmin() {
 		common.ErrorStrResp(c, "You are not an admin", 403)
 		c.Abort()
+	} else if c.Request.Context().Value(conf.APITokenKey) != nil {
+		common.ErrorStrResp(c, "API tokens can't be used for admin", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
+
+// AuthNotAPIToken rejects the requests authenticated by an api token,
+// it guards the account settings, so a token can't change the account or issue new tokens
+func AuthNotAPIToken(c *gin.Context) {
+	if c.Request.Context().Value(conf.APITokenKey) != nil {
+		common.ErrorStrResp(c, "API tokens can't be used here", 403)
+		c.Abort()
 	} else {
 		c.Next()
 	}
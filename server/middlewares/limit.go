@@ -3,8 +3,13 @@ package middlewares
 import (
 	"io"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 func MaxAllowed(n int) gin.HandlerFunc {
@@ -51,3 +56,50 @@ func DownloadRateLimiter(limiter stream.Limiter) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// SharingDownLimiter limits the download speed of the sharing parsed by SharingIdParse,
+// and stops the download once the byte cap of the sharing is reached
+func SharingDownLimiter(c *gin.Context) {
+	s, err := op.GetSharingById(c.Request.Context().Value(conf.SharingIDKey).(string))
+	if err != nil || !s.HasDownloadLimit() {
+		c.Next()
+		return
+	}
+	var w io.Writer = c.Writer
+	if limiter := sharing.DownloadLimiter(s); limiter != nil {
+		w = &stream.RateLimitWriter{
+			Writer:  w,
+			Limiter: limiter,
+			Ctx:     c,
+		}
+	}
+	if s.MaxBytes > 0 {
+		// the bytes written are counted by the handler, the reservation only lasts for the download
+		r := sharing.NewByteReservation(s)
+		defer r.Release()
+		w = &capWriter{Writer: w, r: r}
+	}
+	c.Writer = &ResponseWriterWrapper{
+		ResponseWriter: c.Writer,
+		WrapWriter:     w,
+	}
+	c.Next()
+}
+
+// capWriter fails the writes beyond the bytes reserved from the byte cap
+type capWriter struct {
+	io.Writer
+	r *sharing.ByteReservation
+}
+
+func (w *capWriter) Write(p []byte) (n int, err error) {
+	reserved := w.r.Reserve(int64(len(p)))
+	n, err = w.Writer.Write(p[:reserved])
+	if int64(n) < reserved {
+		w.r.Unreserve(reserved - int64(n))
+	}
+	if err == nil && reserved < int64(len(p)) {
+		err = errors.WithStack(errs.SharingBytesExceeded)
+	}
+	return n, err
+}
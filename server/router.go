@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
@@ -30,6 +31,9 @@ func Init(e *gin.Engine) {
 	g.Any("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
+	if conf.Conf.Metrics.Enable {
+		g.GET("/metrics", handles.Metrics)
+	}
 	g.GET("/favicon.ico", handles.Favicon)
 	g.GET("/robots.txt", handles.Robots)
 	g.GET("/manifest.json", static.ManifestJSON)
@@ -56,16 +60,19 @@ func Init(e *gin.Engine) {
 	g.HEAD("/ap/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveProxy)
 	g.HEAD("/ae/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveInternalExtract)
 
-	g.GET("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingDown)
-	g.GET("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingDown)
+	g.GET("/z/*path", middlewares.PathParse, downloadLimiter, handles.ArchiveDownload)
+	g.HEAD("/z/*path", middlewares.PathParse, handles.ArchiveDownload)
+
+	g.GET("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, middlewares.SharingDownLimiter, handles.SharingDown)
+	g.GET("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, middlewares.SharingDownLimiter, handles.SharingDown)
 	g.HEAD("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, handles.SharingDown)
 	g.HEAD("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, handles.SharingDown)
-	g.GET("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingArchiveExtract)
-	g.GET("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingArchiveExtract)
+	g.GET("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, middlewares.SharingDownLimiter, handles.SharingArchiveExtract)
+	g.GET("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, middlewares.SharingDownLimiter, handles.SharingArchiveExtract)
 	g.HEAD("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
 	g.HEAD("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
 
-	api := g.Group("/api")
+	api := g.Group("/api", middlewares.ClientInfo(audit.ProtocolWeb))
 	auth := api.Group("", middlewares.Auth(false))
 	webauthn := api.Group("/authn", middlewares.Authn)
 
@@ -73,12 +80,19 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
-	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	account := auth.Group("", middlewares.AuthNotAPIToken)
+	account.POST("/me/update", handles.UpdateCurrent)
+	account.GET("/me/sshkey/list", handles.ListMyPublicKey)
+	account.POST("/me/sshkey/add", handles.AddMyPublicKey)
+	account.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
+	account.GET("/me/tokens/list", handles.ListMyAPITokens)
+	account.POST("/me/tokens/create", handles.CreateMyAPIToken)
+	account.POST("/me/tokens/delete", handles.DeleteMyAPIToken)
+	account.GET("/me/s3key/list", handles.ListMyS3Keys)
+	account.POST("/me/s3key/add", handles.AddMyS3Key)
+	account.POST("/me/s3key/delete", handles.DeleteMyS3Key)
+	account.POST("/auth/2fa/generate", handles.Generate2FA)
+	account.POST("/auth/2fa/verify", handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -105,6 +119,7 @@ func Init(e *gin.Engine) {
 	fsAndShare(api.Group("/fs", middlewares.Auth(true)))
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_sharing(auth.Group("/share", middlewares.AuthNotGuest))
+	api.PUT("/share/put", middlewares.UploadRateLimiter(stream.ClientUploadLimit), handles.SharingPut)
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
@@ -130,8 +145,11 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.POST("/reset_usage", handles.ResetUserUsage)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/s3key/list", handles.ListS3Keys)
+	user.POST("/s3key/delete", handles.DeleteS3Key)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -142,6 +160,7 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/health", handles.ListStorageHealth)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -165,6 +184,7 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_thunder", handles.SetThunder)
 	setting.POST("/set_thunderx", handles.SetThunderX)
 	setting.POST("/set_thunder_browser", handles.SetThunderBrowser)
+	setting.POST("/set_ytdlp", handles.SetYtDlp)
 
 	// retain /admin/task API to ensure compatibility with legacy automation scripts
 	_task(g.Group("/task"))
@@ -179,11 +199,54 @@ func admin(g *gin.RouterGroup) {
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
+	index.GET("/job/list", handles.ListIndexJobs)
+	index.GET("/job/get", handles.GetIndexJob)
+	index.POST("/job/create", handles.CreateIndexJob)
+	index.POST("/job/update", handles.UpdateIndexJob)
+	index.POST("/job/delete", handles.DeleteIndexJob)
+	index.POST("/job/run", middlewares.SearchIndex, handles.RunIndexJob)
 
 	scan := g.Group("/scan")
 	scan.POST("/start", handles.StartManualScan)
 	scan.POST("/stop", handles.StopManualScan)
 	scan.GET("/progress", handles.GetManualScanProgress)
+
+	hook := g.Group("/webhook")
+	hook.GET("/list", handles.ListWebhooks)
+	hook.GET("/get", handles.GetWebhook)
+	hook.GET("/events", handles.ListWebhookEvents)
+	hook.POST("/create", handles.CreateWebhook)
+	hook.POST("/update", handles.UpdateWebhook)
+	hook.POST("/delete", handles.DeleteWebhook)
+	hook.POST("/test", handles.TestWebhook)
+	hook.GET("/deliveries", handles.ListWebhookDeliveries)
+
+	auditLog := g.Group("/audit")
+	auditLog.GET("/list", handles.ListAuditLogs)
+	auditLog.GET("/export", handles.ExportAuditLogs)
+	auditLog.POST("/clear", handles.ClearAuditLogs)
+
+	trash := g.Group("/trash")
+	trash.GET("/list", handles.ListTrash)
+	trash.POST("/restore", handles.RestoreTrash)
+	trash.POST("/purge", handles.PurgeTrash)
+
+	syncJob := g.Group("/sync")
+	syncJob.GET("/list", handles.ListSyncJobs)
+	syncJob.GET("/get", handles.GetSyncJob)
+	syncJob.POST("/create", handles.CreateSyncJob)
+	syncJob.POST("/update", handles.UpdateSyncJob)
+	syncJob.POST("/delete", handles.DeleteSyncJob)
+	syncJob.GET("/plan", handles.PlanSyncJob)
+	syncJob.POST("/run", handles.RunSyncJob)
+
+	bundle := g.Group("/bundle")
+	bundle.GET("/export", handles.ExportBundle)
+	bundle.POST("/import", handles.ImportBundle)
+
+	webdavLock := g.Group("/webdav_lock")
+	webdavLock.GET("/list", handles.ListWebdavLocks)
+	webdavLock.POST("/release", handles.ReleaseWebdavLocks)
 }
 
 func fsAndShare(g *gin.RouterGroup) {
@@ -211,11 +274,13 @@ func _fs(g *gin.RouterGroup) {
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
+	g.POST("/archive_download", handles.FsArchiveDownload)
 	// g.POST("/add_aria2", handles.AddOfflineDownload)
 	// g.POST("/add_qbit", handles.AddQbittorrent)
 	// g.POST("/add_transmission", handles.SetTransmission)
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
 	g.POST("/archive/decompress", handles.FsArchiveDecompress)
+	g.POST("/archive/compress", handles.FsArchiveCompress)
 	// Direct upload (client-side upload to storage)
 	g.POST("/get_direct_upload_info", middlewares.FsUp, handles.FsGetDirectUploadInfo)
 }
@@ -232,6 +297,8 @@ func _sharing(g *gin.RouterGroup) {
 	g.POST("/delete", handles.DeleteSharing)
 	g.POST("/enable", handles.SetEnableSharing(false))
 	g.POST("/disable", handles.SetEnableSharing(true))
+	g.GET("/stats", handles.ShareStats)
+	g.GET("/stats/log", handles.ShareAccessLog)
 }
 
 func Cors(r *gin.Engine) {
@@ -0,0 +1,186 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/itsHenry35/gofakes3"
+	log "github.com/sirupsen/logrus"
+)
+
+// getAccessKey returns the access key id claimed by the request, the signature is verified by gofakes3 later
+func getAccessKey(r *http.Request) string {
+	cred := r.URL.Query().Get("X-Amz-Credential")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if v2, ok := strings.CutPrefix(auth, "AWS "); ok {
+			ak, _, _ := strings.Cut(v2, ":")
+			return ak
+		}
+		if i := strings.Index(auth, "Credential="); i >= 0 {
+			cred = auth[i+len("Credential="):]
+		}
+	}
+	ak, _, _ := strings.Cut(cred, "/")
+	return ak
+}
+
+// userAuth accepts the access keys of the users and the personal api tokens, the user is put into the context
+// and only allowed to access the buckets under the base path with the permission of the user.
+// Once any user has an access key, the anonymous requests are rejected even if the static key is not set.
+func userAuth(faker *gofakes3.GoFakeS3, staticKeys map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := checkPresigned(r, time.Now()); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		ak := getAccessKey(r)
+		if _, ok := staticKeys[ak]; ok && ak != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !op.IsAPIToken(ak) && !op.IsS3Key(ak) {
+			if len(staticKeys) == 0 && op.HasS3Keys() {
+				accessDenied(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		user, secret, err := op.GetS3Credential(ak)
+		if err != nil {
+			log.Debugf("[s3] invalid access key %s: %v", ak, err)
+			faker.DelAuthKeys([]string{ak})
+			// the signature check of gofakes3 rejects the request if any key is left
+			if len(staticKeys) == 0 {
+				accessDenied(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		faker.AddAuthKeys(map[string]string{ak: secret})
+		if !allowed(r, user) {
+			accessDenied(w)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), conf.UserKey, user)))
+	})
+}
+
+func allowed(r *http.Request, user *model.User) bool {
+	if !user.CanS3Read() {
+		return false
+	}
+	switch r.Method {
+	case http.MethodPut, http.MethodPost, http.MethodDelete:
+		if !user.CanS3Write() {
+			return false
+		}
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if !user.CanWrite() {
+			return false
+		}
+	case http.MethodPost:
+		// multi delete or multipart upload
+		if _, ok := r.URL.Query()["delete"]; ok {
+			if !user.CanRemove() {
+				return false
+			}
+		} else if !user.CanWrite() {
+			return false
+		}
+	case http.MethodDelete:
+		// aborting a multipart upload removes nothing stored
+		if r.URL.Query().Get("uploadId") != "" {
+			if !user.CanWrite() {
+				return false
+			}
+		} else if !user.CanRemove() {
+			return false
+		}
+	}
+	if !inBasePath(user, r.URL.Path) {
+		return false
+	}
+	if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+		src, _, _ = strings.Cut(src, "?")
+		if src, err := url.PathUnescape(src); err != nil || !inBasePath(user, src) {
+			return false
+		}
+	}
+	return true
+}
+
+// inBasePath checks the bucket and key in the url path are under the base path of the user,
+// listing the buckets is always allowed and filtered by ListBuckets
+func inBasePath(user *model.User, urlPath string) bool {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(urlPath, "/"), "/")
+	if bucketName == "" {
+		return true
+	}
+	bucket, err := getBucketByName(bucketName)
+	if err != nil {
+		// let gofakes3 report the missing bucket
+		return true
+	}
+	return utils.IsSubPath(user.BasePath, path.Join(bucket.Path, key))
+}
+
+// errAccessDenied is not defined by gofakes3, whose Status maps it to 500
+const errAccessDenied gofakes3.ErrorCode = "AccessDenied"
+
+// maxPresignExpires is the longest validity of a presigned url accepted by S3
+const maxPresignExpires = 7 * 24 * time.Hour
+
+// presignClockSkew tolerates the clock of the client signing the url
+const presignClockSkew = 15 * time.Minute
+
+// checkPresigned validates the SigV4 query string of a presigned url, the signature itself is verified by gofakes3
+func checkPresigned(r *http.Request, now time.Time) error {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Signature") == "" {
+		return nil
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+	default:
+		return gofakes3.ErrorMessagef(gofakes3.ErrInvalidArgument, "%s requests can't be presigned", r.Method)
+	}
+	if query.Get("X-Amz-Algorithm") != "AWS4-HMAC-SHA256" {
+		return gofakes3.ErrorMessage(gofakes3.ErrInvalidArgument, "X-Amz-Algorithm only supports AWS4-HMAC-SHA256")
+	}
+	date, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrInvalidArgument, "X-Amz-Date is malformed")
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires <= 0 || time.Duration(expires)*time.Second > maxPresignExpires {
+		return gofakes3.ErrorMessagef(gofakes3.ErrInvalidArgument,
+			"X-Amz-Expires must be between 1 and %d seconds", int64(maxPresignExpires/time.Second))
+	}
+	if date.After(now.Add(presignClockSkew)) {
+		return gofakes3.ErrorMessage(gofakes3.ErrRequestTimeTooSkewed, "Request is not valid yet")
+	}
+	if now.After(date.Add(time.Duration(expires) * time.Second)) {
+		return gofakes3.ErrorMessage(errAccessDenied, "Request has expired")
+	}
+	return nil
+}
+
+func accessDenied(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+}
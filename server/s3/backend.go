@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -52,8 +53,12 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 	if err != nil {
 		return nil, err
 	}
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
+		if user != nil && !utils.IsSubPath(user.BasePath, b.Path) {
+			continue
+		}
 		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
@@ -298,6 +303,9 @@ func (b *s3Backend) PutObject(
 	}
 
 	err = fs.PutDirectly(ctx, reqPath, stream)
+	if errs.IsQuotaError(err) {
+		return result, gofakes3.ErrorInvalidArgument("Content-Length", strconv.FormatInt(size, 10), err.Error())
+	}
 	if err != nil {
 		return result, err
 	}
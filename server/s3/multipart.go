@@ -0,0 +1,577 @@
+package s3
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/itsHenry35/gofakes3"
+	"github.com/itsHenry35/gofakes3/signature"
+	xml "github.com/minio/xxml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// multipartUploadExpiry is how long an upload without any new part is kept,
+// the expired uploads are cleaned when a new upload is initiated
+const multipartUploadExpiry = 24 * time.Hour
+
+type multipartPart struct {
+	number   int
+	etag     string
+	size     int64
+	modified time.Time
+}
+
+type multipartUpload struct {
+	id        string
+	bucket    string
+	key       string
+	owner     string
+	meta      map[string]string
+	dir       string
+	initiated time.Time
+
+	mu         sync.Mutex
+	parts      map[int]*multipartPart
+	lastActive time.Time
+	// done is set once the upload is completed or aborted
+	done bool
+}
+
+func (u *multipartUpload) partPath(number int) string {
+	return filepath.Join(u.dir, strconv.Itoa(number))
+}
+
+// multipartServer handles the multipart uploads instead of gofakes3, which keeps all the parts in memory.
+// The parts are staged in the temp dir, and streamed into one put of the backend on completion.
+type multipartServer struct {
+	backend      gofakes3.Backend
+	authRequired bool
+	next         http.Handler
+	root         string
+
+	mu      sync.Mutex
+	uploads map[string]*multipartUpload
+}
+
+func newMultipartServer(backend gofakes3.Backend, authRequired bool, next http.Handler) *multipartServer {
+	root := filepath.Join(conf.Conf.TempDir, "s3-multipart")
+	// the uploads are not kept across restarts
+	if err := os.RemoveAll(root); err != nil {
+		log.Warnf("[s3] failed clean multipart uploads: %v", err)
+	}
+	return &multipartServer{
+		backend:      backend,
+		authRequired: authRequired,
+		next:         next,
+		root:         root,
+		uploads:      make(map[string]*multipartUpload),
+	}
+}
+
+func (m *multipartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	_, uploads := query["uploads"]
+	uploadID := query.Get("uploadId")
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	var handle func(w http.ResponseWriter, r *http.Request, bucket, key string) error
+	switch {
+	case uploads && key == "" && r.Method == http.MethodGet:
+		handle = m.listUploads
+	case uploads && key != "" && r.Method == http.MethodPost:
+		handle = m.initiate
+	case uploadID != "" && key != "":
+		switch r.Method {
+		case http.MethodPut:
+			handle = m.putPart
+		case http.MethodGet:
+			handle = m.listParts
+		case http.MethodDelete:
+			handle = m.abort
+		case http.MethodPost:
+			handle = m.complete
+		}
+	}
+	if handle == nil {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+	// gofakes3 verifies the signatures in its own handler, so it has to be done here as well
+	if m.authRequired || getAccessKey(r) != "" {
+		if result := verifySignature(r); result != signature.ErrNone {
+			resp := signature.GetAPIError(result)
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(resp.HTTPStatusCode)
+			_, _ = w.Write(signature.EncodeAPIErrorToResponse(resp))
+			return
+		}
+	}
+	if err := handle(w, r, bucket, key); err != nil {
+		writeError(w, r, err)
+	}
+}
+
+func verifySignature(r *http.Request) signature.ErrorCode {
+	result := signature.V4SignVerify(r)
+	if result == signature.ErrUnsupportAlgorithm {
+		result = signature.V2SignVerify(r)
+	}
+	return result
+}
+
+// uploadOwner identifies who initiates the upload, the user if the request is signed with the key of a user,
+// otherwise the static access key or the anonymous
+func uploadOwner(r *http.Request) string {
+	if user, ok := r.Context().Value(conf.UserKey).(*model.User); ok && user != nil {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+	return "key:" + getAccessKey(r)
+}
+
+// getUpload returns the upload initiated by the owner of the request,
+// the uploads of the others are reported as missing, so their ids can't be probed
+func (m *multipartServer) getUpload(r *http.Request, bucket, key string) (*multipartUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.uploads[r.URL.Query().Get("uploadId")]
+	if !ok || u.bucket != bucket || u.key != key || u.owner != uploadOwner(r) {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	return u, nil
+}
+
+// remove drops the upload and its parts, u.mu must be held
+func (m *multipartServer) remove(u *multipartUpload) {
+	u.done = true
+	m.mu.Lock()
+	delete(m.uploads, u.id)
+	m.mu.Unlock()
+	if err := os.RemoveAll(u.dir); err != nil {
+		log.Warnf("[s3] failed remove parts of upload %s: %v", u.id, err)
+	}
+}
+
+func (m *multipartServer) purgeExpired() {
+	m.mu.Lock()
+	var expired []*multipartUpload
+	for _, u := range m.uploads {
+		expired = append(expired, u)
+	}
+	m.mu.Unlock()
+	for _, u := range expired {
+		u.mu.Lock()
+		if !u.done && time.Since(u.lastActive) > multipartUploadExpiry {
+			log.Infof("[s3] multipart upload %s of %s/%s expired", u.id, u.bucket, u.key)
+			m.remove(u)
+		}
+		u.mu.Unlock()
+	}
+}
+
+func (m *multipartServer) initiate(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	if _, err := getBucketByName(bucket); err != nil {
+		return err
+	}
+	m.purgeExpired()
+	now := time.Now()
+	u := &multipartUpload{
+		id:         random.String(32),
+		bucket:     bucket,
+		key:        key,
+		owner:      uploadOwner(r),
+		meta:       make(map[string]string),
+		initiated:  now,
+		parts:      make(map[int]*multipartPart),
+		lastActive: now,
+	}
+	u.dir = filepath.Join(m.root, u.id)
+	if err := os.MkdirAll(u.dir, 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	for k := range r.Header {
+		if k == "Content-Type" || strings.HasPrefix(k, "X-Amz-Meta-") {
+			u.meta[k] = r.Header.Get(k)
+		}
+	}
+	m.mu.Lock()
+	m.uploads[u.id] = u
+	m.mu.Unlock()
+	return writeXML(w, gofakes3.InitiateMultipartUpload{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: gofakes3.UploadID(u.id),
+	})
+}
+
+func (m *multipartServer) putPart(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	query := r.URL.Query()
+	number, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || number <= 0 || number > gofakes3.MaxUploadPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return gofakes3.ErrorMessage(gofakes3.ErrNotImplemented, "UploadPartCopy is not supported")
+	}
+	u, err := m.getUpload(r, bucket, key)
+	if err != nil {
+		return err
+	}
+	var body io.Reader = r.Body
+	size := r.ContentLength
+	contentSha256 := r.Header.Get("X-Amz-Content-Sha256")
+	if strings.HasPrefix(contentSha256, "STREAMING-") {
+		body = newChunkedReader(r.Body)
+		if size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err != nil {
+			return gofakes3.ErrMissingContentLength
+		}
+	}
+	f, err := os.CreateTemp(u.dir, "part-*")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return gofakes3.ErrNoSuchUpload
+		}
+		return errors.WithStack(err)
+	}
+	md5Hash, sha256Hash := md5.New(), sha256.New()
+	n, err := utils.CopyWithBuffer(io.MultiWriter(f, md5Hash, sha256Hash), body)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = checkPart(r, n, size, md5Hash.Sum(nil), sha256Hash.Sum(nil))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	etag := hex.EncodeToString(md5Hash.Sum(nil))
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.done {
+		_ = os.Remove(f.Name())
+		return gofakes3.ErrNoSuchUpload
+	}
+	// a part uploaded again replaces the previous one
+	if err = os.Rename(f.Name(), u.partPath(number)); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.WithStack(err)
+	}
+	now := time.Now()
+	u.parts[number] = &multipartPart{number: number, etag: etag, size: n, modified: now}
+	u.lastActive = now
+	w.Header().Set("ETag", `"`+etag+`"`)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// checkPart verifies the size and the checksums of the part given by the request
+func checkPart(r *http.Request, n, size int64, md5Sum, sha256Sum []byte) error {
+	if size >= 0 && n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	if contentMD5, ok := r.Header["Content-Md5"]; ok {
+		expected, err := base64.StdEncoding.DecodeString(contentMD5[0])
+		if err != nil || len(expected) != md5.Size {
+			return gofakes3.ErrInvalidDigest
+		}
+		if string(expected) != string(md5Sum) {
+			return gofakes3.ErrBadDigest
+		}
+	}
+	// the signature only covers the declared hash of the payload
+	if contentSha256 := r.Header.Get("X-Amz-Content-Sha256"); len(contentSha256) == sha256.Size*2 {
+		if !strings.EqualFold(contentSha256, hex.EncodeToString(sha256Sum)) {
+			return gofakes3.ErrorMessage(gofakes3.ErrBadDigest, "The provided 'x-amz-content-sha256' header does not match what was computed.")
+		}
+	}
+	return nil
+}
+
+func (m *multipartServer) listParts(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	query := r.URL.Query()
+	u, err := m.getUpload(r, bucket, key)
+	if err != nil {
+		return err
+	}
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts, err := strconv.Atoi(query.Get("max-parts"))
+	if err != nil || maxParts <= 0 || maxParts > gofakes3.DefaultMaxUploadParts {
+		maxParts = gofakes3.DefaultMaxUploadParts
+	}
+	res := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           bucket,
+		Key:              key,
+		UploadID:         gofakes3.UploadID(u.id),
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	u.mu.Lock()
+	numbers := make([]int, 0, len(u.parts))
+	for number := range u.parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+	if len(numbers) > maxParts {
+		numbers = numbers[:maxParts]
+		res.IsTruncated = true
+	}
+	for _, number := range numbers {
+		p := u.parts[number]
+		res.Parts = append(res.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   p.number,
+			LastModified: gofakes3.NewContentTime(p.modified),
+			ETag:         `"` + p.etag + `"`,
+			Size:         p.size,
+		})
+		res.NextPartNumberMarker = p.number
+	}
+	u.mu.Unlock()
+	return writeXML(w, res)
+}
+
+func (m *multipartServer) listUploads(w http.ResponseWriter, r *http.Request, bucket, _ string) error {
+	if _, err := getBucketByName(bucket); err != nil {
+		return err
+	}
+	prefix := r.URL.Query().Get("prefix")
+	owner := uploadOwner(r)
+	res := gofakes3.ListMultipartUploadsResult{
+		Bucket:     bucket,
+		Prefix:     prefix,
+		MaxUploads: gofakes3.MaxUploadsLimit,
+		Uploads:    []gofakes3.ListMultipartUploadItem{},
+	}
+	m.mu.Lock()
+	for _, u := range m.uploads {
+		if u.bucket == bucket && u.owner == owner && strings.HasPrefix(u.key, prefix) {
+			res.Uploads = append(res.Uploads, gofakes3.ListMultipartUploadItem{
+				Key:       u.key,
+				UploadID:  gofakes3.UploadID(u.id),
+				Initiated: gofakes3.NewContentTime(u.initiated),
+			})
+		}
+	}
+	m.mu.Unlock()
+	slices.SortFunc(res.Uploads, func(a, b gofakes3.ListMultipartUploadItem) int {
+		if a.Key != b.Key {
+			return strings.Compare(a.Key, b.Key)
+		}
+		return a.Initiated.Compare(b.Initiated.Time)
+	})
+	if len(res.Uploads) > gofakes3.MaxUploadsLimit {
+		res.Uploads = res.Uploads[:gofakes3.MaxUploadsLimit]
+		res.IsTruncated = true
+	}
+	return writeXML(w, res)
+}
+
+func (m *multipartServer) abort(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	u, err := m.getUpload(r, bucket, key)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.done {
+		return gofakes3.ErrNoSuchUpload
+	}
+	m.remove(u)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (m *multipartServer) complete(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	u, err := m.getUpload(r, bucket, key)
+	if err != nil {
+		return err
+	}
+	// 10000 parts take about 1MB
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4<<20))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var in gofakes3.CompleteMultipartUploadRequest
+	if err = xml.Unmarshal(body, &in); err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrMalformedXML, err.Error())
+	}
+	if len(in.Parts) == 0 {
+		return gofakes3.ErrorMessage(gofakes3.ErrMalformedXML, "no part is given")
+	}
+
+	// hold the upload during the put, so no part can be changed
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.done {
+		return gofakes3.ErrNoSuchUpload
+	}
+	paths := make([]string, 0, len(in.Parts))
+	etagHash := md5.New()
+	var size int64
+	for i, p := range in.Parts {
+		if i > 0 && p.PartNumber <= in.Parts[i-1].PartNumber {
+			return gofakes3.ErrInvalidPartOrder
+		}
+		part, ok := u.parts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != part.etag {
+			return gofakes3.ErrInvalidPart
+		}
+		raw, _ := hex.DecodeString(part.etag)
+		etagHash.Write(raw)
+		size += part.size
+		paths = append(paths, u.partPath(p.PartNumber))
+	}
+	reader := &partsReader{paths: paths}
+	_, err = m.backend.PutObject(r.Context(), bucket, key, u.meta, reader, size)
+	_ = reader.Close()
+	if err != nil {
+		// keep the parts, so the client can retry the completion
+		return err
+	}
+	m.remove(u)
+	return writeXML(w, gofakes3.CompleteMultipartUploadResult{
+		Location: r.URL.Path,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     `"` + hex.EncodeToString(etagHash.Sum(nil)) + "-" + strconv.Itoa(len(paths)) + `"`,
+	})
+}
+
+// partsReader reads the part files one after another, only one of them is opened at a time
+type partsReader struct {
+	paths []string
+	cur   *os.File
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.cur == nil {
+			if len(p.paths) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(p.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			p.cur, p.paths = f, p.paths[1:]
+		}
+		n, err := p.cur.Read(b)
+		if err == io.EOF {
+			_ = p.cur.Close()
+			p.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.cur == nil {
+		return nil
+	}
+	err := p.cur.Close()
+	p.cur = nil
+	return err
+}
+
+// chunkedReader decodes the aws-chunked body of the streaming uploads,
+// the chunk signatures are not verified, the same as gofakes3
+type chunkedReader struct {
+	r      *bufio.Reader
+	remain int64
+	eof    bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		// <hex size>[;chunk-signature=<signature>]\r\n
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		sizeStr, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeStr, 16, 64)
+		if err != nil || size < 0 {
+			return 0, errors.Errorf("invalid chunk size %q", sizeStr)
+		}
+		if size == 0 {
+			// the trailing headers are ignored
+			c.eof = true
+			return 0, io.EOF
+		}
+		c.remain = size
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	if err == nil && c.remain == 0 {
+		// every chunk ends with \r\n
+		_, err = c.r.Discard(2)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var resp gofakes3.Error
+	switch e := err.(type) {
+	case gofakes3.ErrorCode:
+		resp = &gofakes3.ErrorResponse{Code: e, Message: string(e)}
+	case gofakes3.Error:
+		resp = e
+	default:
+		log.Errorf("[s3] multipart upload: %+v", err)
+		resp = &gofakes3.ErrorResponse{Code: gofakes3.ErrInternal, Message: "Internal Error"}
+	}
+	status := resp.ErrorCode().Status()
+	if resp.ErrorCode() == errAccessDenied {
+		status = http.StatusForbidden
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write([]byte(xml.Header))
+		_ = xml.NewEncoder(w).Encode(resp)
+	}
+}
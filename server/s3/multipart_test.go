@@ -0,0 +1,175 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/itsHenry35/gofakes3"
+)
+
+func TestChunkedReader(t *testing.T) {
+	body := "5;chunk-signature=abc\r\nhello\r\n6;chunk-signature=def\r\n world\r\n0;chunk-signature=ghi\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n"
+	got, err := io.ReadAll(newChunkedReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("got %q", got)
+	}
+	// the body ends before the last chunk
+	if _, err = io.ReadAll(newChunkedReader(strings.NewReader("5\r\nhel"))); err != io.ErrUnexpectedEOF {
+		t.Errorf("expect unexpected EOF, got %v", err)
+	}
+}
+
+func TestCheckPresigned(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		method, query string
+		ok            bool
+	}{
+		{http.MethodGet, "", true},
+		{http.MethodGet, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T115500Z&X-Amz-Expires=600&X-Amz-Signature=x", true},
+		{http.MethodPut, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T115500Z&X-Amz-Expires=600&X-Amz-Signature=x", true},
+		{http.MethodDelete, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T115500Z&X-Amz-Expires=600&X-Amz-Signature=x", false},
+		// expired
+		{http.MethodGet, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T114000Z&X-Amz-Expires=600&X-Amz-Signature=x", false},
+		// longer than 7 days
+		{http.MethodGet, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T115500Z&X-Amz-Expires=604801&X-Amz-Signature=x", false},
+		// signed in the future
+		{http.MethodGet, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T130000Z&X-Amz-Expires=600&X-Amz-Signature=x", false},
+		{http.MethodGet, "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240501T115500Z&X-Amz-Signature=x", false},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest(tt.method, "http://localhost/bucket/key?"+tt.query, nil)
+		if err := checkPresigned(r, now); (err == nil) != tt.ok {
+			t.Errorf("%s %s: got %v", tt.method, tt.query, err)
+		}
+	}
+}
+
+// putBackend records the object put by the completion
+type putBackend struct {
+	gofakes3.Backend
+	key  string
+	data []byte
+}
+
+func (b *putBackend) PutObject(ctx context.Context, bucketName, key string, meta map[string]string, input io.Reader, size int64) (gofakes3.PutObjectResult, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return gofakes3.PutObjectResult{}, err
+	}
+	if int64(len(data)) != size {
+		return gofakes3.PutObjectResult{}, gofakes3.ErrIncompleteBody
+	}
+	b.key, b.data = key, data
+	return gofakes3.PutObjectResult{}, nil
+}
+
+func newTestUpload(t *testing.T, m *multipartServer, owner string) *multipartUpload {
+	u := &multipartUpload{
+		id:         "upload",
+		bucket:     "bucket",
+		key:        "key",
+		owner:      owner,
+		meta:       map[string]string{},
+		dir:        filepath.Join(m.root, "upload"),
+		parts:      make(map[int]*multipartPart),
+		lastActive: time.Now(),
+	}
+	if err := os.MkdirAll(u.dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	m.uploads[u.id] = u
+	return u
+}
+
+func multipartRequest(method, query, body string, user *model.User) *http.Request {
+	r := httptest.NewRequest(method, "/bucket/key?uploadId=upload"+query, strings.NewReader(body))
+	if user != nil {
+		r = r.WithContext(context.WithValue(r.Context(), conf.UserKey, user))
+	}
+	return r
+}
+
+func TestMultipartComplete(t *testing.T) {
+	backend := &putBackend{}
+	m := &multipartServer{backend: backend, root: t.TempDir(), uploads: make(map[string]*multipartUpload)}
+	user := &model.User{ID: 1}
+	newTestUpload(t, m, "user:1")
+
+	// the parts are uploaded out of order, and the second one is replaced
+	etags := make(map[int]string)
+	for _, p := range []struct {
+		number int
+		data   string
+	}{{2, "old"}, {1, "hello "}, {2, "world"}} {
+		w := httptest.NewRecorder()
+		r := multipartRequest(http.MethodPut, "&partNumber="+strconv.Itoa(p.number), p.data, user)
+		if err := m.putPart(w, r, "bucket", "key"); err != nil {
+			t.Fatal(err)
+		}
+		etags[p.number] = w.Header().Get("ETag")
+	}
+	// a part with the wrong md5 is rejected
+	r := multipartRequest(http.MethodPut, "&partNumber=3", "bad", user)
+	r.Header.Set("Content-Md5", "AAAAAAAAAAAAAAAAAAAAAA==")
+	if err := m.putPart(httptest.NewRecorder(), r, "bucket", "key"); err != gofakes3.ErrBadDigest {
+		t.Errorf("expect bad digest, got %v", err)
+	}
+
+	complete := func(parts ...int) error {
+		body := "<CompleteMultipartUpload>"
+		for _, n := range parts {
+			body += "<Part><PartNumber>" + strconv.Itoa(n) + "</PartNumber><ETag>" + etags[n] + "</ETag></Part>"
+		}
+		body += "</CompleteMultipartUpload>"
+		return m.complete(httptest.NewRecorder(), multipartRequest(http.MethodPost, "", body, user), "bucket", "key")
+	}
+	if err := complete(2, 1); err != gofakes3.ErrInvalidPartOrder {
+		t.Errorf("expect invalid part order, got %v", err)
+	}
+	if err := complete(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if backend.key != "key" || string(backend.data) != "hello world" {
+		t.Errorf("got %s: %q", backend.key, backend.data)
+	}
+	if _, ok := m.uploads["upload"]; ok {
+		t.Error("the completed upload should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(m.root, "upload")); !os.IsNotExist(err) {
+		t.Errorf("the parts should be removed, got %v", err)
+	}
+}
+
+func TestMultipartOwner(t *testing.T) {
+	m := &multipartServer{root: t.TempDir(), uploads: make(map[string]*multipartUpload)}
+	newTestUpload(t, m, "user:1")
+	other := &model.User{ID: 2}
+
+	if err := m.putPart(httptest.NewRecorder(), multipartRequest(http.MethodPut, "&partNumber=1", "data", other), "bucket", "key"); err != gofakes3.ErrNoSuchUpload {
+		t.Errorf("put part: expect no such upload, got %v", err)
+	}
+	if err := m.abort(httptest.NewRecorder(), multipartRequest(http.MethodDelete, "", "", other), "bucket", "key"); err != gofakes3.ErrNoSuchUpload {
+		t.Errorf("abort: expect no such upload, got %v", err)
+	}
+	body := "<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>x</ETag></Part></CompleteMultipartUpload>"
+	if err := m.complete(httptest.NewRecorder(), multipartRequest(http.MethodPost, "", body, nil), "bucket", "key"); err != gofakes3.ErrNoSuchUpload {
+		t.Errorf("complete: expect no such upload, got %v", err)
+	}
+	if err := m.abort(httptest.NewRecorder(), multipartRequest(http.MethodDelete, "", "", &model.User{ID: 1}), "bucket", "key"); err != nil {
+		t.Errorf("abort by the owner: %v", err)
+	}
+}
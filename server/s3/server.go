@@ -13,15 +13,23 @@ import (
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
 	var newLogger logger
+	authPair := authlistResolver()
+	// gofakes3 keeps the keys of the users added later in the map as well
+	fakerAuthPair := make(map[string]string, len(authPair))
+	for k, v := range authPair {
+		fakerAuthPair[k] = v
+	}
+	backend := newBackend()
 	faker := gofakes3.New(
-		newBackend(),
+		backend,
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
+		gofakes3.WithV4Auth(fakerAuthPair),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
 
-	return faker.Server(), nil
+	multipart := newMultipartServer(backend, len(authPair) != 0, faker.Server())
+	return userAuth(faker, authPair, multipart), nil
 }
@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -23,12 +24,12 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: webdav.NewDBLS(),
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
 	}
-	dav.Use(WebDAVAuth)
+	dav.Use(middlewares.ClientInfo(audit.ProtocolWebDAV), WebDAVAuth)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAV)
@@ -82,7 +83,12 @@ func WebDAVAuth(c *gin.Context) {
 				c.Next()
 				return
 			}
+			// an api token is checked like a password of any user
+			ok = op.IsAPIToken(bt)
+			password = bt
 		}
+	}
+	if !ok {
 		if c.Request.Method == "OPTIONS" {
 			common.GinWithValue(c, conf.UserKey, guest)
 			c.Next()
@@ -147,6 +153,10 @@ func WebDAVAuth(c *gin.Context) {
 }
 
 func tryLogin(username, password string) (*model.User, bool) {
+	if op.IsAPIToken(password) {
+		user, _, err := op.GetUserByAPIToken(password)
+		return user, err == nil && (username == "" || username == user.Username)
+	}
 	user, err := op.GetUserByName(username)
 	if err == nil {
 		err = user.ValidateRawPassword(password)
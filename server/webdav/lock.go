@@ -109,6 +109,9 @@ type LockDetails struct {
 	// ZeroDepth is whether the lock has zero depth. If it does not have zero
 	// depth, it has infinite depth.
 	ZeroDepth bool
+	// Temporary is whether the lock is taken by the handler itself while
+	// serving a request, rather than by a LOCK HTTP request.
+	Temporary bool
 }
 
 // NewMemLS returns a new in-memory LockSystem.
@@ -0,0 +1,223 @@
+package webdav
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// NewDBLS returns a LockSystem storing the locks in the database, so the locks survive restarts
+// and are shared by the instances using the same database. Holding a lock by Confirm is only
+// tracked in the current instance. The temporary locks never expire, so the ones left by the
+// previous run of this instance are removed here, otherwise their resources would be locked forever.
+func NewDBLS() LockSystem {
+	instance := conf.Conf.InstanceID
+	if err := db.DeleteTemporaryWebdavLocks(instance); err != nil {
+		log.Errorf("failed delete temporary webdav locks: %+v", err)
+	}
+	return &dbLS{instance: instance, held: make(map[string]struct{})}
+}
+
+type dbLS struct {
+	instance string
+	mu       sync.Mutex
+	held     map[string]struct{}
+}
+
+func lockDetails(l *model.WebdavLock) LockDetails {
+	d := LockDetails{
+		Root:      l.Root,
+		Duration:  infiniteTimeout,
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+		Temporary: l.Temporary,
+	}
+	if l.Timeout >= 0 {
+		d.Duration = time.Duration(l.Timeout) * time.Second
+	}
+	return d
+}
+
+// setTimeout rounds the duration up to seconds, since the Timeout header is in seconds
+func setTimeout(l *model.WebdavLock, now time.Time, duration time.Duration) {
+	if duration < 0 {
+		l.Timeout, l.ExpiresAt = infiniteTimeout, nil
+		return
+	}
+	l.Timeout = int64((duration + time.Second - 1) / time.Second)
+	expiresAt := now.Add(duration)
+	l.ExpiresAt = &expiresAt
+}
+
+func expired(l *model.WebdavLock, now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// get returns the unexpired lock of the token, or nil if there is none
+func (m *dbLS) get(now time.Time, token string) (*model.WebdavLock, error) {
+	l, err := db.GetWebdavLockByToken(token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expired(l, now) {
+		return nil, db.DeleteWebdavLockByToken(token)
+	}
+	return l, nil
+}
+
+func (m *dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var t0, t1 string
+	var err error
+	if name0 != "" {
+		if t0, err = m.lookup(now, slashClean(name0), conditions...); t0 == "" || err != nil {
+			return nil, confirmErr(err)
+		}
+	}
+	if name1 != "" {
+		if t1, err = m.lookup(now, slashClean(name1), conditions...); t1 == "" || err != nil {
+			return nil, confirmErr(err)
+		}
+	}
+
+	// Don't hold the same lock twice.
+	if t1 == t0 {
+		t1 = ""
+	}
+	for _, t := range []string{t0, t1} {
+		if t != "" {
+			m.held[t] = struct{}{}
+		}
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.held, t0)
+		delete(m.held, t1)
+	}, nil
+}
+
+func confirmErr(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrConfirmationFailed
+}
+
+// lookup returns the token of the lock locking the named resource as memLS.lookup does
+func (m *dbLS) lookup(now time.Time, name string, conditions ...Condition) (string, error) {
+	for _, c := range conditions {
+		if _, ok := m.held[c.Token]; ok || c.Token == "" {
+			continue
+		}
+		l, err := m.get(now, c.Token)
+		if err != nil {
+			return "", err
+		}
+		if l == nil {
+			continue
+		}
+		if name == l.Root {
+			return l.Token, nil
+		}
+		if l.ZeroDepth {
+			continue
+		}
+		if l.Root == "/" || strings.HasPrefix(name, l.Root+"/") {
+			return l.Token, nil
+		}
+	}
+	return "", nil
+}
+
+// isAncestor reports whether a is b or an ancestor of b
+func isAncestor(a, b string) bool {
+	return a == b || a == "/" || strings.HasPrefix(b, a+"/")
+}
+
+func (m *dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := db.DeleteExpiredWebdavLocks(now); err != nil {
+		return "", err
+	}
+	root := slashClean(details.Root)
+	l := &model.WebdavLock{
+		Token:     "opaquelocktoken:" + uuid.NewString(),
+		Root:      root,
+		ZeroDepth: details.ZeroDepth,
+		OwnerXML:  details.OwnerXML,
+		Temporary: details.Temporary,
+		Instance:  m.instance,
+	}
+	setTimeout(l, now, details.Duration)
+	created, err := db.CreateWebdavLock(l, func(old *model.WebdavLock) bool {
+		if expired(old, now) {
+			return false
+		}
+		// the same rules as memLS.canCreate
+		if old.Root == root {
+			return true
+		}
+		if isAncestor(root, old.Root) {
+			return !details.ZeroDepth
+		}
+		return isAncestor(old.Root, root) && !old.ZeroDepth
+	})
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "", ErrLocked
+	}
+	return l.Token, nil
+}
+
+func (m *dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err := m.get(now, token)
+	if err != nil {
+		return LockDetails{}, err
+	}
+	if l == nil {
+		return LockDetails{}, ErrNoSuchLock
+	}
+	if _, ok := m.held[token]; ok {
+		return LockDetails{}, ErrLocked
+	}
+	setTimeout(l, now, duration)
+	if err = db.UpdateWebdavLockTimeout(l); err != nil {
+		return LockDetails{}, err
+	}
+	return lockDetails(l), nil
+}
+
+func (m *dbLS) Unlock(now time.Time, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err := m.get(now, token)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return ErrNoSuchLock
+	}
+	if _, ok := m.held[token]; ok {
+		return ErrLocked
+	}
+	return db.DeleteWebdavLockByToken(token)
+}
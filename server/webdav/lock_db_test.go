@@ -0,0 +1,141 @@
+package webdav
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestDBLS(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewDBLS()
+	a, err := m.Create(now, LockDetails{Root: "/a", Duration: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Create /a: %v", err)
+	}
+	for _, d := range []LockDetails{
+		{Root: "/a", ZeroDepth: true, Duration: -1},
+		{Root: "/a/b/c", ZeroDepth: true, Duration: -1},
+		{Root: "/", Duration: -1},
+	} {
+		if _, err = m.Create(now, d); err != ErrLocked {
+			t.Errorf("Create %+v: got %v, want ErrLocked", d, err)
+		}
+	}
+	root, err := m.Create(now, LockDetails{Root: "/", ZeroDepth: true, Duration: -1})
+	if err != nil {
+		t.Fatalf("Create zero depth /: %v", err)
+	}
+
+	release, err := m.Confirm(now, "/a/b", "", Condition{Token: a})
+	if err != nil {
+		t.Fatalf("Confirm /a/b: %v", err)
+	}
+	if _, err = m.Confirm(now, "/a", "", Condition{Token: a}); err != ErrConfirmationFailed {
+		t.Errorf("Confirm held lock: got %v, want ErrConfirmationFailed", err)
+	}
+	if err = m.Unlock(now, a); err != ErrLocked {
+		t.Errorf("Unlock held lock: got %v, want ErrLocked", err)
+	}
+	release()
+	if _, err = m.Confirm(now, "/b", "", Condition{Token: a}); err != ErrConfirmationFailed {
+		t.Errorf("Confirm /b: got %v, want ErrConfirmationFailed", err)
+	}
+
+	ld, err := m.Refresh(now.Add(5*time.Second), a, 20*time.Second)
+	if err != nil || ld.Root != "/a" || ld.Duration != 20*time.Second {
+		t.Fatalf("Refresh: got %+v, %v", ld, err)
+	}
+	if _, err = m.Refresh(now.Add(25*time.Second), a, time.Second); err != ErrNoSuchLock {
+		t.Errorf("Refresh expired lock: got %v, want ErrNoSuchLock", err)
+	}
+	if _, err = m.Create(now.Add(25*time.Second), LockDetails{Root: "/a/b", Duration: -1}); err != nil {
+		t.Errorf("Create after expiry: %v", err)
+	}
+	if err = m.Unlock(now, root); err != nil {
+		t.Errorf("Unlock /: %v", err)
+	}
+	if err = m.Unlock(now, root); err != ErrNoSuchLock {
+		t.Errorf("Unlock twice: got %v, want ErrNoSuchLock", err)
+	}
+}
+
+func TestDBLSConcurrentCreate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewDBLS()
+	var (
+		wg      sync.WaitGroup
+		created atomic.Int32
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Create(now, LockDetails{Root: "/concurrent", Duration: -1})
+			if err == nil {
+				created.Add(1)
+			} else if err != ErrLocked {
+				t.Errorf("Create: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := created.Load(); n != 1 {
+		t.Errorf("created %d exclusive locks, want 1", n)
+	}
+}
+
+func TestDBLSTemporary(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewDBLS()
+	if _, err := m.Create(now, LockDetails{Root: "/temp", ZeroDepth: true, Duration: -1, Temporary: true}); err != nil {
+		t.Fatalf("Create temporary: %v", err)
+	}
+	kept, err := m.Create(now, LockDetails{Root: "/kept", Duration: -1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	instance := conf.Conf.InstanceID
+	defer func() { conf.Conf.InstanceID = instance }()
+	conf.Conf.InstanceID = "other"
+	other := NewDBLS()
+	if _, err = other.Create(now, LockDetails{Root: "/other", ZeroDepth: true, Duration: -1, Temporary: true}); err != nil {
+		t.Fatalf("Create temporary of the other instance: %v", err)
+	}
+	// a restart removes the temporary lock left by the server, not the ones of the other instances
+	conf.Conf.InstanceID = instance
+	m = NewDBLS()
+	if _, err = m.Create(now, LockDetails{Root: "/temp", Duration: -1}); err != nil {
+		t.Errorf("Create after restart: %v", err)
+	}
+	if _, err = m.Create(now, LockDetails{Root: "/other", Duration: -1}); err != ErrLocked {
+		t.Errorf("Create on the temporary lock of the other instance: got %v, want ErrLocked", err)
+	}
+	if err = m.Unlock(now, kept); err != nil {
+		t.Errorf("Unlock the lock of LOCK: %v", err)
+	}
+	locks, _, err := db.GetWebdavLocks(1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range locks {
+		if l.Root == "" {
+			t.Errorf("the sentinel should not be listed: %+v", l)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -173,35 +174,34 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//fi, err := f.Stat()
-	//if err != nil {
-	//	return nil, err
-	//}
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, deadProps map[xml.Name]Property, pnames []xml.Name) ([]Propstat, error) {
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
-
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
+	var (
+		quota       *quotaInfo
+		quotaLoaded bool
+	)
 	for _, pn := range pnames {
 		// If this file has dead properties, check if they contain pn.
 		if dp, ok := deadProps[pn]; ok {
 			pstatOK.Props = append(pstatOK.Props, dp)
 			continue
 		}
+		if _, ok := quotaProps[pn]; ok && isDir {
+			if !quotaLoaded {
+				var err error
+				if quota, err = findQuota(ctx, name); err != nil {
+					return nil, err
+				}
+				quotaLoaded = true
+			}
+			if quota != nil {
+				pstatOK.Props = append(pstatOK.Props, quotaProp(quota, pn))
+				continue
+			}
+		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
 			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
@@ -222,27 +222,9 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//fi, err := f.Stat()
-	//if err != nil {
-	//	return nil, err
-	//}
+func propnames(ctx context.Context, ls LockSystem, fi model.Obj, deadProps map[xml.Name]Property) ([]xml.Name, error) {
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
-
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
 		if prop.findFn != nil && (prop.dir || !isDir) {
@@ -263,8 +245,8 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, deadProps map[xml.Name]Property, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, fi, deadProps)
 	if err != nil {
 		return nil, err
 	}
@@ -278,7 +260,14 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, deadProps, pnames)
+}
+
+// isLiveProp reports whether the property is computed by this package and can not be patched
+func isLiveProp(pn xml.Name) bool {
+	_, live := liveProps[pn]
+	_, quota := quotaProps[pn]
+	return live || quota
 }
 
 // Patch patches the properties of resource name. The return values are
@@ -288,7 +277,7 @@ func patch(ctx context.Context, ls LockSystem, name string, patches []Proppatch)
 loop:
 	for _, patch := range patches {
 		for _, p := range patch.Props {
-			if _, ok := liveProps[p.XMLName]; ok {
+			if isLiveProp(p.XMLName) {
 				conflict = true
 				break loop
 			}
@@ -304,7 +293,7 @@ loop:
 		}
 		for _, patch := range patches {
 			for _, p := range patch.Props {
-				if _, ok := liveProps[p.XMLName]; ok {
+				if isLiveProp(p.XMLName) {
 					pstatForbidden.Props = append(pstatForbidden.Props, Property{XMLName: p.XMLName})
 				} else {
 					pstatFailedDep.Props = append(pstatFailedDep.Props, Property{XMLName: p.XMLName})
@@ -314,40 +303,45 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
-
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
-	pstat := Propstat{Status: http.StatusForbidden}
+	var set, remove []model.WebdavProp
+	pstat := Propstat{Status: http.StatusOK}
 	for _, patch := range patches {
 		for _, p := range patch.Props {
+			prop := model.WebdavProp{Space: p.XMLName.Space, Local: p.XMLName.Local, Lang: p.Lang, InnerXML: string(p.InnerXML)}
+			if patch.Remove {
+				remove = append(remove, prop)
+			} else {
+				set = append(set, prop)
+			}
+			// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
+			// "The contents of the prop XML element must only list the names of
+			// properties to which the result in the status element applies."
 			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
 		}
 	}
+	if err := db.PatchWebdavProps(name, set, remove); err != nil {
+		return nil, err
+	}
 	return []Propstat{pstat}, nil
 }
 
+// deadPropsUnder returns the dead properties of the resource name and its descendants within depth by path
+func deadPropsUnder(name string, depth int) (map[string]map[xml.Name]Property, error) {
+	props, err := db.GetWebdavPropsUnder(name, depth)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]map[xml.Name]Property)
+	for _, p := range props {
+		if res[p.Path] == nil {
+			res[p.Path] = make(map[xml.Name]Property)
+		}
+		pn := xml.Name{Space: p.Space, Local: p.Local}
+		res[p.Path][pn] = Property{XMLName: pn, Lang: p.Lang, InnerXML: []byte(p.InnerXML)}
+	}
+	return res, nil
+}
+
 func escapeXML(s string) string {
 	for i := 0; i < len(s); i++ {
 		// As an optimization, if s contains only ASCII letters, digits or a
@@ -0,0 +1,40 @@
+package webdav
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestDeadPropsUnderDepth(t *testing.T) {
+	for _, p := range []string{"/props", "/props/a", "/props/a/b", "/props_x", "/props/c"} {
+		if err := db.PatchWebdavProps(p, []model.WebdavProp{{Space: "ns", Local: "color", InnerXML: "red"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{0, "/props"},
+		{1, "/props,/props/a,/props/c"},
+		{infiniteDepth, "/props,/props/a,/props/a/b,/props/c"},
+	}
+	for _, tt := range tests {
+		props, err := deadPropsUnder("/props", tt.depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for p := range props {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		if got := strings.Join(paths, ","); got != tt.want {
+			t.Errorf("depth %d: got %s, want %s", tt.depth, got, tt.want)
+		}
+	}
+}
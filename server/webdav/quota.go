@@ -0,0 +1,70 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/quota"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// quotaInfo is the quota of a collection as defined in RFC 4331.
+type quotaInfo struct {
+	available, used int64
+}
+
+// quotaProps contains the RFC 4331 properties. They are computed by the path of
+// the collection, and are not returned by allprop and propname as the RFC
+// recommends, since they may be expensive to compute.
+//
+// See https://www.rfc-editor.org/rfc/rfc4331
+var quotaProps = map[xml.Name]func(*quotaInfo) int64{
+	{Space: "DAV:", Local: "quota-available-bytes"}: func(q *quotaInfo) int64 { return q.available },
+	{Space: "DAV:", Local: "quota-used-bytes"}:      func(q *quotaInfo) int64 { return q.used },
+}
+
+func quotaProp(q *quotaInfo, pn xml.Name) Property {
+	return Property{XMLName: pn, InnerXML: []byte(strconv.FormatInt(quotaProps[pn](q), 10))}
+}
+
+// findQuota returns the disk usage of the storage the collection name belongs to, limited
+// by the quota of the user if configured. It returns nil if neither of them is known.
+func findQuota(ctx context.Context, name string) (*quotaInfo, error) {
+	var q *quotaInfo
+	if storage, _, err := op.GetStorageAndActualPath(name); err == nil {
+		details, err := op.GetStorageDetails(ctx, storage)
+		if err == nil {
+			q = &quotaInfo{
+				available: int64(details.FreeSpace),
+				used:      int64(details.TotalSpace - details.FreeSpace),
+			}
+		} else if !errors.Is(err, errs.NotImplement) {
+			log.Warnf("failed get details of storage [%s]: %+v", storage.GetStorage().MountPath, err)
+		}
+	}
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return q, nil
+	}
+	limit, _ := quota.Limits(user)
+	if limit == 0 {
+		return q, nil
+	}
+	used, err := db.GetUserUsedBytes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the user can use neither more than the quota left nor more than the storage has
+	available := max(limit-used, 0)
+	if q != nil {
+		available = min(available, q.available)
+	}
+	return &quotaInfo{available: available, used: used}, nil
+}
@@ -108,6 +108,7 @@ func (h *Handler) lock(now time.Time, root string) (token string, status int, er
 		Root:      root,
 		Duration:  infiniteTimeout,
 		ZeroDepth: true,
+		Temporary: true,
 	})
 	if err != nil {
 		if err == ErrLocked {
@@ -375,6 +376,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
+	if errors.Is(err, errs.FileTooLarge) {
+		return http.StatusRequestEntityTooLarge, err
+	}
+	if errors.Is(err, errs.QuotaExceeded) {
+		return http.StatusInsufficientStorage, err
+	}
 
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if err != nil {
@@ -676,6 +683,12 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		return status, err
 	}
 
+	// load the dead properties of the resources walked at once instead of a query per resource
+	deadProps, err := deadPropsUnder(reqPath, depth)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
 	mw := multistatusWriter{w: w}
 
 	walkFn := func(reqPath string, info model.Obj, err error) error {
@@ -684,7 +697,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, info, deadProps[reqPath])
 			if err != nil {
 				return err
 			}
@@ -694,9 +707,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, deadProps[reqPath], pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, deadProps[reqPath], pf.Prop)
 		}
 		if err != nil {
 			return err